@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keyloom/web-api/core"
+	token_dtos "github.com/keyloom/web-api/dtos/token"
+)
+
+// Context key of the validated access token of an admin API request
+const accessTokenContextKey = "access_token"
+
+// Protects a route of the Keyloom Web API. Requests need an access token
+// issued for the API that carries every given scope.
+func requireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, ok := validateAccessToken(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+		if !slices.Contains(payload.Aud, core.KeyloomAPIIdentifier) {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "the access token is not meant for the Keyloom Web API"})
+			return
+		}
+		granted := core.ParseScopes(payload.Scope)
+		for _, scope := range scopes {
+			if !slices.Contains(granted, scope) {
+				c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+strings.Join(scopes, " ")+`"`)
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient_scope", "error_description": "the " + scope + " scope is required"})
+				return
+			}
+		}
+		c.Set(accessTokenContextKey, payload)
+		c.Next()
+	}
+}
+
+// Validates the access token of a request to a protected resource, sent with
+// the Bearer or DPoP scheme. Sender-constrained tokens must come with a DPoP
+// proof or the client certificate they are bound to. Writes the error
+// response and returns false when the request must be rejected.
+func validateAccessToken(c *gin.Context) (*token_dtos.JWTPayload, bool) {
+	tokenString, ok := bearerToken(c)
+	usesDPoP := false
+	if !ok {
+		tokenString, usesDPoP = dpopToken(c)
+	}
+	if tokenString == "" {
+		c.Header("WWW-Authenticate", `Bearer`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "a bearer token is required"})
+		return nil, false
+	}
+	payload, err := newTokenService().ValidateToken(tokenString)
+	if err != nil {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "invalid access token"})
+		return nil, false
+	}
+	// DPoP-bound tokens are only accepted with a proof of the same key
+	if usesDPoP || (payload.Cnf != nil && payload.Cnf.Jkt != "") {
+		target, err := requestURL(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "invalid token configuration"})
+			return nil, false
+		}
+		if err := newDPoPVerifier(false).VerifyResourceRequest(c.Request, target, payload); err != nil {
+			c.Header("WWW-Authenticate", `DPoP error="invalid_dpop_proof", algs="`+strings.Join(core.DPoPAlgorithms, " ")+`"`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_dpop_proof", "error_description": err.Error()})
+			return nil, false
+		}
+	}
+	if !core.CheckCertificateBinding(payload, clientCertificates(c)) {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "the access token is bound to another client certificate"})
+		return nil, false
+	}
+	return payload, true
+}
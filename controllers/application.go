@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/keyloom/web-api/core"
@@ -17,23 +18,29 @@ var _ core.Controller = (*ApplicationController)(nil)
 func (ac *ApplicationController) RegisterRoutes(engine *gin.Engine) {
 	appGroup := engine.Group("/applications")
 	{
-		appGroup.POST("/", ac.CreateHandler)
-		appGroup.GET("/", ac.GetAllHandler)
-		appGroup.GET("/:id", ac.GetByIDHandler)
-		appGroup.PUT("/:id", ac.UpdateHandler)
+		appGroup.POST("/", requireScopes(core.ManageApplicationsScope), ac.CreateHandler)
+		appGroup.GET("/", requireScopes(core.ViewApplicationsScope), ac.GetAllHandler)
+		appGroup.GET("/:id", requireScopes(core.ViewApplicationsScope), ac.GetByIDHandler)
+		appGroup.PUT("/:id", requireScopes(core.ManageApplicationsScope), ac.UpdateHandler)
+		appGroup.POST("/:id/secrets", requireScopes(core.ManageApplicationsScope), ac.CreateSecretHandler)
+		appGroup.DELETE("/:id/secrets/:secret_id", requireScopes(core.ManageApplicationsScope), ac.DeleteSecretHandler)
+		appGroup.GET("/:id/logout-deliveries", requireScopes(core.ViewApplicationsScope), ac.GetLogoutDeliveriesHandler)
 	}
 }
 
 // @Summary Create a new application
 // @Param body body application_dtos.CreateApplicationDTO true "Application creation data"
-// @Description Create a new application with the provided name, description, and audience ID
+// @Description Create a new application with the provided name, description and type. The grant types and token endpoint authentication method must be allowed for the type.
 // @Accept json
 // @Produce json
 // @Success 201 {object} entities.Application
 // @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
 // @Failure 500 {object} interface{}
 // @Router /applications/ [post]
 // @Tags Applications
+// @Security ApiKeyAuth
 func (ac *ApplicationController) CreateHandler(c *gin.Context) {
 	var dto application_dtos.CreateApplicationDTO
 	if err := c.ShouldBindJSON(&dto); err != nil {
@@ -45,7 +52,56 @@ func (ac *ApplicationController) CreateHandler(c *gin.Context) {
 	entity.Description = dto.Description
 	entity.ClientID = primitive.NewObjectID().Hex()
 
-	entity.Save()
+	applicationType := dto.Type
+	if applicationType == "" {
+		applicationType = core.SPAApplicationType
+	}
+	err := entity.SetType(applicationType, dto.GrantTypes, dto.TokenEndpointAuthMethod)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if dto.JWKSURI != "" && !isWebURL(dto.JWKSURI) {
+		c.JSON(400, gin.H{"error": "Invalid jwks_uri"})
+		return
+	}
+	err = entity.SetJWKS(dto.JWKS, dto.JWKSURI)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	err = entity.SetTLSClientAuth(dto.TLSClientAuthSubjectDN, dto.TLSClientCertificateBoundAccessTokens)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	entity.DPoPBoundAccessTokens = dto.DPoPBoundAccessTokens
+	entity.RequirePushedAuthorizationRequests = dto.RequirePushedAuthorizationRequests
+	err = entity.SetRequestObjects(dto.RequestURIs, dto.RequireSignedRequestObject)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	for _, redirectURI := range dto.PostLogoutRedirectURIs {
+		if !isValidRedirectURI(redirectURI) {
+			c.JSON(400, gin.H{"error": "Invalid post logout redirect URI: " + redirectURI})
+			return
+		}
+	}
+	if dto.PostLogoutRedirectURIs != nil {
+		entity.PostLogoutRedirectURIs = dto.PostLogoutRedirectURIs
+	}
+	err = entity.SetLogoutURIs(dto.BackchannelLogoutURI, dto.BackchannelLogoutSessionRequired, dto.FrontchannelLogoutURI, dto.FrontchannelLogoutSessionRequired)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = entity.Save()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create application"})
+		return
+	}
 	c.JSON(201, entity)
 }
 
@@ -56,10 +112,13 @@ func (ac *ApplicationController) CreateHandler(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {array} []entities.Application
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
 // @Failure 404 {object} interface{}
 // @Failure 500 {object} interface{}
 // @Router /applications/ [get]
 // @Tags Applications
+// @Security ApiKeyAuth
 func (ac *ApplicationController) GetAllHandler(c *gin.Context) {
 	topParam := c.DefaultQuery("limit", "10")
 	pageParam := c.DefaultQuery("page", "1")
@@ -88,9 +147,12 @@ func (ac *ApplicationController) GetAllHandler(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} entities.Application
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
 // @Failure 404 {object} interface{}
 // @Router /applications/{id} [get]
 // @Tags Applications
+// @Security ApiKeyAuth
 func (ac *ApplicationController) GetByIDHandler(c *gin.Context) {
 	id := c.Param("id")
 	applicationEntity := (&entities.Application{}).LoadByID(id)
@@ -103,19 +165,22 @@ func (ac *ApplicationController) GetByIDHandler(c *gin.Context) {
 
 // @Summary Update an existing application
 // @Param id path string true "Application ID"
-// @Param body body application_dtos.CreateApplicationDTO true "Application update data"
-// @Description Update an existing application's name and description
+// @Param body body application_dtos.UpdateApplicationDTO true "Application update data"
+// @Description Update an existing application's name, description, type and keys. Omitted grant types and authentication method are kept unless the type changes, omitted keys, request URIs, logout URIs and flags are kept.
 // @Accept json
 // @Produce json
 // @Success 200 {object} entities.Application
 // @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
 // @Failure 404 {object} interface{}
 // @Failure 500 {object} interface{}
 // @Router /applications/{id} [put]
 // @Tags Applications
+// @Security ApiKeyAuth
 func (ac *ApplicationController) UpdateHandler(c *gin.Context) {
 	id := c.Param("id")
-	var dto application_dtos.CreateApplicationDTO
+	var dto application_dtos.UpdateApplicationDTO
 	if err := c.ShouldBindJSON(&dto); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
@@ -127,10 +192,222 @@ func (ac *ApplicationController) UpdateHandler(c *gin.Context) {
 	}
 	applicationEntity.Name = dto.Name
 	applicationEntity.Description = dto.Description
-	err := applicationEntity.Save()
+
+	applicationType := dto.Type
+	grantTypes := dto.GrantTypes
+	authMethod := dto.TokenEndpointAuthMethod
+	if applicationType == "" || applicationType == applicationEntity.Type {
+		applicationType = applicationEntity.Type
+		if grantTypes == nil {
+			grantTypes = applicationEntity.GrantTypes
+		}
+		if authMethod == "" {
+			authMethod = applicationEntity.TokenEndpointAuthMethod
+		}
+	}
+	err := applicationEntity.SetType(applicationType, grantTypes, authMethod)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	// registered keys are kept when none are given
+	jwks, jwksURI := dto.JWKS, dto.JWKSURI
+	if jwks == nil && jwksURI == "" {
+		jwks, jwksURI = applicationEntity.JWKS, applicationEntity.JWKSURI
+	}
+	if jwksURI != "" && !isWebURL(jwksURI) {
+		c.JSON(400, gin.H{"error": "Invalid jwks_uri"})
+		return
+	}
+	err = applicationEntity.SetJWKS(jwks, jwksURI)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	subjectDN := dto.TLSClientAuthSubjectDN
+	if subjectDN == "" {
+		subjectDN = applicationEntity.TLSClientAuthSubjectDN
+	}
+	boundAccessTokens := applicationEntity.TLSClientCertificateBoundAccessTokens
+	if dto.TLSClientCertificateBoundAccessTokens != nil {
+		boundAccessTokens = *dto.TLSClientCertificateBoundAccessTokens
+	}
+	err = applicationEntity.SetTLSClientAuth(subjectDN, boundAccessTokens)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if dto.DPoPBoundAccessTokens != nil {
+		applicationEntity.DPoPBoundAccessTokens = *dto.DPoPBoundAccessTokens
+	}
+	if dto.RequirePushedAuthorizationRequests != nil {
+		applicationEntity.RequirePushedAuthorizationRequests = *dto.RequirePushedAuthorizationRequests
+	}
+	requestURIs := dto.RequestURIs
+	if requestURIs == nil {
+		requestURIs = applicationEntity.RequestURIs
+	}
+	requireSigned := applicationEntity.RequireSignedRequestObject
+	if dto.RequireSignedRequestObject != nil {
+		requireSigned = *dto.RequireSignedRequestObject
+	}
+	err = applicationEntity.SetRequestObjects(requestURIs, requireSigned)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	for _, redirectURI := range dto.PostLogoutRedirectURIs {
+		if !isValidRedirectURI(redirectURI) {
+			c.JSON(400, gin.H{"error": "Invalid post logout redirect URI: " + redirectURI})
+			return
+		}
+	}
+	if dto.PostLogoutRedirectURIs != nil {
+		applicationEntity.PostLogoutRedirectURIs = dto.PostLogoutRedirectURIs
+	}
+	backchannelLogoutURI := dto.BackchannelLogoutURI
+	if backchannelLogoutURI == "" {
+		backchannelLogoutURI = applicationEntity.BackchannelLogoutURI
+	}
+	frontchannelLogoutURI := dto.FrontchannelLogoutURI
+	if frontchannelLogoutURI == "" {
+		frontchannelLogoutURI = applicationEntity.FrontchannelLogoutURI
+	}
+	backchannelSessionRequired := applicationEntity.BackchannelLogoutSessionRequired
+	if dto.BackchannelLogoutSessionRequired != nil {
+		backchannelSessionRequired = *dto.BackchannelLogoutSessionRequired
+	}
+	frontchannelSessionRequired := applicationEntity.FrontchannelLogoutSessionRequired
+	if dto.FrontchannelLogoutSessionRequired != nil {
+		frontchannelSessionRequired = *dto.FrontchannelLogoutSessionRequired
+	}
+	err = applicationEntity.SetLogoutURIs(backchannelLogoutURI, backchannelSessionRequired, frontchannelLogoutURI, frontchannelSessionRequired)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = applicationEntity.Save()
 	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to update application"})
 		return
 	}
 	c.JSON(200, applicationEntity)
 }
+
+// @Summary Create a client secret
+// @Param id path string true "Application ID"
+// @Param body body application_dtos.CreateClientSecretDTO true "Client secret data"
+// @Description Generate a new client secret. The plaintext is only returned in this response, existing secrets stay valid so they can be rotated without downtime.
+// @Accept json
+// @Produce json
+// @Success 201 {object} application_dtos.ClientSecretResponse
+// @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
+// @Failure 404 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /applications/{id}/secrets [post]
+// @Tags Applications
+// @Security ApiKeyAuth
+func (ac *ApplicationController) CreateSecretHandler(c *gin.Context) {
+	var dto application_dtos.CreateClientSecretDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if dto.ExpireAt != 0 && dto.ExpireAt <= time.Now().Unix() {
+		c.JSON(400, gin.H{"error": "expire_at must be in the future"})
+		return
+	}
+	applicationEntity := (&entities.Application{}).LoadByID(c.Param("id"))
+	if applicationEntity == nil {
+		c.JSON(404, gin.H{"error": "Application not found"})
+		return
+	}
+	if !applicationEntity.UsesClientSecret() {
+		c.JSON(400, gin.H{"error": "Application does not authenticate with client secrets"})
+		return
+	}
+
+	plaintext, secret, err := applicationEntity.AddClientSecret(dto.Name, dto.ExpireAt)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to generate client secret"})
+		return
+	}
+	err = applicationEntity.Save()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to save client secret"})
+		return
+	}
+	c.JSON(201, application_dtos.ClientSecretResponse{
+		ID:           secret.ID,
+		Name:         secret.Name,
+		ClientSecret: plaintext,
+		Hint:         secret.Hint,
+		ExpireAt:     secret.ExpireAt,
+		CreatedAt:    secret.CreatedAt,
+	})
+}
+
+// @Summary Delete a client secret
+// @Param id path string true "Application ID"
+// @Param secret_id path string true "Client secret ID"
+// @Description Revoke a client secret, clients using it can no longer authenticate
+// @Success 204 "No Content"
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
+// @Failure 404 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /applications/{id}/secrets/{secret_id} [delete]
+// @Tags Applications
+// @Security ApiKeyAuth
+func (ac *ApplicationController) DeleteSecretHandler(c *gin.Context) {
+	applicationEntity := (&entities.Application{}).LoadByID(c.Param("id"))
+	if applicationEntity == nil {
+		c.JSON(404, gin.H{"error": "Application not found"})
+		return
+	}
+	if !applicationEntity.RemoveClientSecret(c.Param("secret_id")) {
+		c.JSON(404, gin.H{"error": "Client secret not found"})
+		return
+	}
+	err := applicationEntity.Save()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to delete client secret"})
+		return
+	}
+	c.Status(204)
+}
+
+// @Summary Get the back-channel logout deliveries of an application
+// @Param id path string true "Application ID"
+// @Param limit query int false "Number of deliveries to return" default(10)
+// @Param page query int false "Page number" default(1)
+// @Description Retrieve the logout notifications sent to the back-channel logout URI of the application, newest first, with their delivery status
+// @Produce json
+// @Success 200 {array} entities.LogoutDelivery
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
+// @Failure 404 {object} interface{}
+// @Router /applications/{id}/logout-deliveries [get]
+// @Tags Applications
+// @Security ApiKeyAuth
+func (ac *ApplicationController) GetLogoutDeliveriesHandler(c *gin.Context) {
+	applicationEntity := (&entities.Application{}).LoadByID(c.Param("id"))
+	if applicationEntity == nil {
+		c.JSON(404, gin.H{"error": "Application not found"})
+		return
+	}
+
+	top, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || top <= 0 {
+		top = 10
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	c.JSON(200, (&entities.LogoutDelivery{}).LoadByClientID(applicationEntity.ClientID, top, page))
+}
@@ -0,0 +1,496 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keyloom/web-api/core"
+	authorize_dtos "github.com/keyloom/web-api/dtos/authorize"
+	"github.com/keyloom/web-api/entities"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuthorizeController struct{}
+
+var _ core.Controller = (*AuthorizeController)(nil)
+
+func (ac *AuthorizeController) RegisterRoutes(engine *gin.Engine) {
+	authorizeGroup := engine.Group("/authorize")
+	{
+		authorizeGroup.GET("", ac.AuthorizeHandler)
+		authorizeGroup.POST("/login", ac.LoginHandler)
+		authorizeGroup.POST("/consent", ac.ConsentHandler)
+	}
+	parGroup := engine.Group("/par")
+	{
+		parGroup.POST("", ac.PushedAuthorizationHandler)
+	}
+}
+
+// @Summary Authorization endpoint
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string false "Registered redirect URI"
+// @Param scope query string false "Space delimited scopes"
+// @Param state query string false "Opaque value returned to the client"
+// @Param code_challenge query string true "PKCE code challenge"
+// @Param code_challenge_method query string true "PKCE code challenge method, must be S256"
+// @Param nonce query string false "OpenID Connect nonce, returned in the ID token"
+// @Param prompt query string false "Space delimited prompts: none fails unless the user is signed in and consented, login forces a new sign in, consent forces the consent page"
+// @Param max_age query integer false "Maximum age in seconds of the sign in of the user, older sessions require a new sign in"
+// @Param resource query []string false "Resource indicators of the resource servers the tokens are meant for" collectionFormat(multi)
+// @Param request query string false "Request object, signed with a registered key of the client unless it allows unsigned ones, its parameters take precedence"
+// @Param request_uri query string false "request_uri returned by /par, whose parameters replace all others but client_id, or registered URL of a request object"
+// @Description Start an authorization code flow. Users signed in to a browser session continue without signing in again, others get the sign in page.
+// @Produce html
+// @Success 200 {string} string "Sign in or consent page"
+// @Success 302 {string} string "Redirect to the client with the authorization code"
+// @Failure 302 {string} string "Redirect to the client with an error, login_required or consent_required with prompt=none"
+// @Failure 400 {string} string "Error page"
+// @Router /authorize [get]
+// @Tags Authorization
+func (ac *AuthorizeController) AuthorizeHandler(c *gin.Context) {
+	var dto authorize_dtos.AuthorizationRequestDTO
+	if err := c.ShouldBindQuery(&dto); err != nil {
+		renderAuthorizeError(c, "invalid_request", err.Error())
+		return
+	}
+
+	// The client and redirect URI must be verified before anything is
+	// sent back to the client, otherwise errors are shown to the user
+	application := (&entities.Application{}).LoadByClientID(dto.ClientID)
+	if application == nil {
+		renderAuthorizeError(c, "invalid_client", "unknown client_id")
+		return
+	}
+	// only the parameters pushed by the client are used along with their request_uri
+	if strings.HasPrefix(dto.RequestURI, core.RequestURIPrefix) {
+		ac.authorizePushedRequest(c, application, dto.RequestURI)
+		return
+	}
+	// the redirect URI may come from the request object, errors cannot be sent to it yet
+	if code, err := applyRequestObject(application, &dto); err != nil {
+		renderAuthorizeError(c, code, err.Error())
+		return
+	}
+	redirectURI, ok := resolveRedirectURI(application, dto.RedirectURI)
+	if !ok {
+		renderAuthorizeError(c, "invalid_request", "redirect_uri is not registered for this client")
+		return
+	}
+
+	if application.RequirePushedAuthorizationRequests {
+		redirectWithError(c, redirectURI, dto.State, "invalid_request", "the client must use pushed authorization requests")
+		return
+	}
+
+	request, code, err := newAuthorizationRequest(application, dto, redirectURI)
+	if err != nil {
+		redirectWithError(c, redirectURI, dto.State, code, err.Error())
+		return
+	}
+	err = request.Save()
+	if err != nil {
+		redirectWithError(c, redirectURI, dto.State, "server_error", "failed to store the authorization request")
+		return
+	}
+	ac.authenticate(c, request, application)
+}
+
+// @Summary Pushed authorization request endpoint
+// @Param response_type formData string true "Must be code"
+// @Param client_id formData string false "Client ID when not using HTTP Basic authentication"
+// @Param client_secret formData string false "Client secret of confidential clients"
+// @Param client_assertion_type formData string false "urn:ietf:params:oauth:client-assertion-type:jwt-bearer for private_key_jwt and client_secret_jwt clients"
+// @Param client_assertion formData string false "JWT authenticating the client"
+// @Param redirect_uri formData string false "Registered redirect URI"
+// @Param scope formData string false "Space delimited scopes"
+// @Param state formData string false "Opaque value returned to the client"
+// @Param code_challenge formData string true "PKCE code challenge"
+// @Param code_challenge_method formData string true "PKCE code challenge method, must be S256"
+// @Param nonce formData string false "OpenID Connect nonce, returned in the ID token"
+// @Param prompt formData string false "Space delimited prompts: none, login or consent"
+// @Param max_age formData integer false "Maximum age in seconds of the sign in of the user"
+// @Param resource formData []string false "Resource indicators of the resource servers the tokens are meant for" collectionFormat(multi)
+// @Param request formData string false "Request object, its parameters take precedence"
+// @Description Push the parameters of an authorization request from the back channel (RFC 9126). The returned request_uri is passed to /authorize along with the client_id.
+// @Accept application/x-www-form-urlencoded
+// @Produce json
+// @Success 201 {object} authorize_dtos.PushedAuthorizationResponse
+// @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /par [post]
+// @Tags Authorization
+func (ac *AuthorizeController) PushedAuthorizationHandler(c *gin.Context) {
+	application, err := identifyClient(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client", "error_description": err.Error()})
+		return
+	}
+
+	var dto authorize_dtos.AuthorizationRequestDTO
+	if err := c.ShouldBind(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+	if dto.RequestURI != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "request_uri cannot be pushed"})
+		return
+	}
+	if code, err := applyRequestObject(application, &dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": code, "error_description": err.Error()})
+		return
+	}
+	redirectURI, ok := resolveRedirectURI(application, dto.RedirectURI)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "redirect_uri is not registered for this client"})
+		return
+	}
+
+	request, code, err := newAuthorizationRequest(application, dto, redirectURI)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": code, "error_description": err.Error()})
+		return
+	}
+	request.Pushed = true
+	request.ExpireAt = time.Now().Add(core.PushedAuthorizationRequestLifetime)
+	err = request.Save()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "failed to store the authorization request"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, authorize_dtos.PushedAuthorizationResponse{
+		RequestURI: core.RequestURIPrefix + request.Handle,
+		ExpiresIn:  int64(core.PushedAuthorizationRequestLifetime.Seconds()),
+	})
+}
+
+// Starts the sign in for a request pushed by the client
+func (ac *AuthorizeController) authorizePushedRequest(c *gin.Context, application *entities.Application, requestURI string) {
+	handle, found := strings.CutPrefix(requestURI, core.RequestURIPrefix)
+	pushed := (&entities.AuthorizationRequest{}).LoadPushedByHandle(handle)
+	if !found || pushed == nil || pushed.ClientID != application.ClientID {
+		renderAuthorizeError(c, "invalid_request_uri", "the request_uri is invalid or has expired")
+		return
+	}
+	request, err := pushed.Redeem()
+	if err != nil {
+		renderAuthorizeError(c, "invalid_request_uri", "the request_uri is invalid or has expired")
+		return
+	}
+	ac.authenticate(c, request, application)
+}
+
+// Continues a stored authorization request as the user of the browser
+// session, unless the client asks for a new sign in or the session is older
+// than max_age. The sign in page is rendered otherwise, which prompt=none
+// forbids.
+func (ac *AuthorizeController) authenticate(c *gin.Context, request *entities.AuthorizationRequest, application *entities.Application) {
+	session := currentSession(c)
+	if session != nil && !slices.Contains(request.Prompt, core.LoginPrompt) &&
+		(request.MaxAge == nil || time.Now().Unix()-session.AuthTime <= *request.MaxAge) {
+		request.UserID = session.UserID
+		request.AuthTime = session.AuthTime
+		request.SessionID = session.SID
+		ac.requestConsent(c, request, application)
+		return
+	}
+
+	if slices.Contains(request.Prompt, core.NonePrompt) {
+		request.Delete()
+		redirectWithError(c, request.RedirectURI, request.State, "login_required", "the user is not signed in")
+		return
+	}
+	c.HTML(http.StatusOK, "login.html", gin.H{
+		"ApplicationName": application.Name,
+		"RequestID":       request.Handle,
+		"CSRFToken":       request.CSRFToken,
+	})
+}
+
+// Validates the parameters of an authorization request of the application,
+// whose redirect URI was already resolved. Returns the OAuth error code
+// along with the error when they are invalid.
+func newAuthorizationRequest(application *entities.Application, dto authorize_dtos.AuthorizationRequestDTO, redirectURI string) (*entities.AuthorizationRequest, string, error) {
+	if dto.ResponseType != core.CodeResponseType {
+		return nil, "unsupported_response_type", errors.New("only the code response type is supported")
+	}
+	if !application.AllowsGrantType(core.CodeGrant) {
+		return nil, "unauthorized_client", errors.New("the client may not use the authorization code grant")
+	}
+	if dto.CodeChallengeMethod != core.CodeChallengeMethodS256 || !core.IsValidCodeChallenge(dto.CodeChallenge) {
+		return nil, "invalid_request", errors.New("a S256 code_challenge is required")
+	}
+
+	var err error
+	request := (&entities.AuthorizationRequest{}).CreateNew()
+	request.ClientID = application.ClientID
+	request.RedirectURI = redirectURI
+	request.RedirectURIProvided = dto.RedirectURI != ""
+	// the user's grant narrows the scopes down further once they sign in
+	request.Scopes = core.EffectiveScopes(core.ParseScopes(dto.Scope), application.Scopes, application.Scopes)
+	if len(request.Scopes) == 0 {
+		return nil, "invalid_scope", errors.New("none of the requested scopes are allowed")
+	}
+	if len(dto.Resources) > 0 {
+		request.Resources, err = resolveAudiences(application, dto.Resources, nil)
+		if err != nil {
+			return nil, "invalid_target", err
+		}
+	}
+	request.State = dto.State
+	request.CodeChallenge = dto.CodeChallenge
+	request.CodeChallengeMethod = dto.CodeChallengeMethod
+	request.Nonce = dto.Nonce
+	request.Prompt = strings.Fields(dto.Prompt)
+	if slices.Contains(request.Prompt, core.NonePrompt) && len(request.Prompt) > 1 {
+		return nil, "invalid_request", errors.New("prompt none cannot be combined with other values")
+	}
+	request.MaxAge = dto.MaxAge
+	return request, "", nil
+}
+
+// @Summary Sign in for a pending authorization request
+// @Param request_id formData string true "Pending authorization request ID"
+// @Param csrf_token formData string true "Anti-CSRF value of the sign in page"
+// @Param username formData string true "User email"
+// @Param password formData string true "User password"
+// @Description Authenticate the user and start their browser session, then ask for consent or redirect back to the client with an authorization code
+// @Accept application/x-www-form-urlencoded
+// @Produce html
+// @Success 200 {string} string "Consent page"
+// @Success 302 {string} string "Redirect to the client with the authorization code"
+// @Failure 400 {string} string "Error page"
+// @Failure 401 {string} string "Sign in page with an error"
+// @Failure 403 {string} string "Sign in page, the user is disabled"
+// @Router /authorize/login [post]
+// @Tags Authorization
+func (ac *AuthorizeController) LoginHandler(c *gin.Context) {
+	var dto authorize_dtos.LoginDTO
+	if err := c.ShouldBind(&dto); err != nil {
+		renderAuthorizeError(c, "invalid_request", err.Error())
+		return
+	}
+
+	request := (&entities.AuthorizationRequest{}).LoadByHandle(dto.RequestID)
+	if request == nil || !request.CheckCSRFToken(dto.CSRFToken) {
+		renderAuthorizeError(c, "invalid_request", "the authorization request has expired, please start over")
+		return
+	}
+	application := (&entities.Application{}).LoadByClientID(request.ClientID)
+	if application == nil {
+		renderAuthorizeError(c, "invalid_client", "unknown client_id")
+		return
+	}
+
+	// verify user credentials
+	user := (&entities.User{}).LoadByEmail(dto.Username)
+	if user == nil || !user.CheckPassword(dto.Password) {
+		c.HTML(http.StatusUnauthorized, "login.html", gin.H{
+			"ApplicationName": application.Name,
+			"RequestID":       request.Handle,
+			"CSRFToken":       request.CSRFToken,
+			"Error":           "Invalid email or password",
+		})
+		return
+	}
+	if user.IsDisabled() {
+		c.HTML(http.StatusForbidden, "login.html", gin.H{
+			"ApplicationName": application.Name,
+			"RequestID":       request.Handle,
+			"CSRFToken":       request.CSRFToken,
+			"Error":           "This account is disabled",
+		})
+		return
+	}
+
+	// the browser session signs the user in to other applications too
+	session, err := startSession(c, user.ID)
+	if err != nil {
+		redirectWithError(c, request.RedirectURI, request.State, "server_error", "failed to start the session")
+		return
+	}
+
+	// remember the user while the request awaits consent
+	request.UserID = user.ID
+	request.AuthTime = session.AuthTime
+	request.SessionID = session.SID
+	ac.requestConsent(c, request, application)
+}
+
+// Asks the signed in user to consent to the request. The consent page is
+// skipped when the user already granted every scope, and cannot be shown
+// with prompt=none.
+func (ac *AuthorizeController) requestConsent(c *gin.Context, request *entities.AuthorizationRequest, application *entities.Application) {
+	grant := (&entities.Grant{}).LoadByUserAndApplication(request.UserID, application.ID)
+	if grant != nil && grant.Covers(request.Scopes) && !slices.Contains(request.Prompt, core.ConsentPrompt) {
+		ac.issueAuthorizationCode(c, request, application, grant)
+		return
+	}
+	if slices.Contains(request.Prompt, core.NonePrompt) {
+		request.Delete()
+		redirectWithError(c, request.RedirectURI, request.State, "consent_required", "the user has not consented to the requested scopes")
+		return
+	}
+
+	err := request.Save()
+	if err != nil {
+		redirectWithError(c, request.RedirectURI, request.State, "server_error", "failed to store the authorization request")
+		return
+	}
+	c.HTML(http.StatusOK, "consent.html", gin.H{
+		"ApplicationName": application.Name,
+		"RequestID":       request.Handle,
+		"CSRFToken":       request.CSRFToken,
+		"Scopes":          describeScopes(application, request.Scopes),
+	})
+}
+
+// @Summary Consent to a pending authorization request
+// @Param request_id formData string true "Pending authorization request ID"
+// @Param csrf_token formData string true "Anti-CSRF value of the consent page"
+// @Param action formData string true "approve or deny"
+// @Description Record the decision of the user signed in to the browser session as a grant and redirect back to the client
+// @Accept application/x-www-form-urlencoded
+// @Produce html
+// @Success 302 {string} string "Redirect to the client with the authorization code or an error"
+// @Failure 400 {string} string "Error page"
+// @Router /authorize/consent [post]
+// @Tags Authorization
+func (ac *AuthorizeController) ConsentHandler(c *gin.Context) {
+	var dto authorize_dtos.ConsentDTO
+	if err := c.ShouldBind(&dto); err != nil {
+		renderAuthorizeError(c, "invalid_request", err.Error())
+		return
+	}
+
+	request := (&entities.AuthorizationRequest{}).LoadByHandle(dto.RequestID)
+	if request == nil || request.UserID == primitive.NilObjectID || !request.CheckCSRFToken(dto.CSRFToken) {
+		renderAuthorizeError(c, "invalid_request", "the authorization request has expired, please start over")
+		return
+	}
+	// only the user signed in to this browser may decide
+	if session := currentSession(c); session == nil || session.UserID != request.UserID {
+		renderAuthorizeError(c, "access_denied", "the user is not signed in to this browser, please start over")
+		return
+	}
+	application := (&entities.Application{}).LoadByClientID(request.ClientID)
+	if application == nil {
+		renderAuthorizeError(c, "invalid_client", "unknown client_id")
+		return
+	}
+
+	if dto.Action != "approve" {
+		request.Delete()
+		redirectWithError(c, request.RedirectURI, request.State, "access_denied", "the user denied the request")
+		return
+	}
+
+	grant, err := (&entities.Grant{}).Consent(request.UserID, application.ID, request.Scopes)
+	if err != nil {
+		redirectWithError(c, request.RedirectURI, request.State, "server_error", "failed to save the consent")
+		return
+	}
+	ac.issueAuthorizationCode(c, request, application, grant)
+}
+
+// Completes the authorization request with an authorization code for the
+// scopes the user granted, and redirects back to the client
+func (ac *AuthorizeController) issueAuthorizationCode(c *gin.Context, request *entities.AuthorizationRequest, application *entities.Application, grant *entities.Grant) {
+	// the request is single use
+	request.Delete()
+
+	scopes := core.EffectiveScopes(request.Scopes, application.Scopes, grant.Scopes)
+	if len(scopes) == 0 {
+		redirectWithError(c, request.RedirectURI, request.State, "invalid_scope", "none of the requested scopes are granted to the application")
+		return
+	}
+
+	// issue the authorization code
+	authorizationCode := (&entities.AuthorizationCode{}).CreateNew()
+	authorizationCode.ClientID = request.ClientID
+	authorizationCode.UserID = request.UserID
+	authorizationCode.RedirectURI = request.RedirectURI
+	authorizationCode.RedirectURIProvided = request.RedirectURIProvided
+	authorizationCode.Scopes = scopes
+	authorizationCode.Resources = request.Resources
+	authorizationCode.CodeChallenge = request.CodeChallenge
+	authorizationCode.CodeChallengeMethod = request.CodeChallengeMethod
+	authorizationCode.Nonce = request.Nonce
+	authorizationCode.AuthTime = request.AuthTime
+	authorizationCode.SessionID = request.SessionID
+	code, err := authorizationCode.Issue()
+	if err != nil {
+		redirectWithError(c, request.RedirectURI, request.State, "server_error", "failed to issue the authorization code")
+		return
+	}
+
+	// remember the client is signed in through the session
+	if request.SessionID != "" {
+		if session := (&entities.Session{}).LoadBySID(request.SessionID); session != nil {
+			session.AddClient(request.ClientID)
+		}
+	}
+
+	params := url.Values{}
+	params.Set("code", code)
+	if request.State != "" {
+		params.Set("state", request.State)
+	}
+	redirectWithParams(c, request.RedirectURI, params)
+}
+
+// Returns the redirect URI to use for the application. The requested URI must
+// exactly match a registered one, and may only be omitted when the
+// application has a single registered URI.
+func resolveRedirectURI(application *entities.Application, requested string) (string, bool) {
+	if requested == "" {
+		if len(application.RedirectURIs) == 1 {
+			return application.RedirectURIs[0], true
+		}
+		return "", false
+	}
+	if !slices.Contains(application.RedirectURIs, requested) {
+		return "", false
+	}
+	return requested, true
+}
+
+func renderAuthorizeError(c *gin.Context, code, description string) {
+	c.HTML(http.StatusBadRequest, "error.html", gin.H{
+		"Error":            code,
+		"ErrorDescription": description,
+	})
+}
+
+func redirectWithError(c *gin.Context, redirectURI, state, code, description string) {
+	params := url.Values{}
+	params.Set("error", code)
+	params.Set("error_description", description)
+	if state != "" {
+		params.Set("state", state)
+	}
+	redirectWithParams(c, redirectURI, params)
+}
+
+// Redirects to the given URI with the parameters added to its query string
+func redirectWithParams(c *gin.Context, redirectURI string, params url.Values) {
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		renderAuthorizeError(c, "invalid_request", "invalid redirect_uri")
+		return
+	}
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	target.RawQuery = query.Encode()
+	c.Redirect(http.StatusFound, target.String())
+}
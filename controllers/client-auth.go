@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keyloom/web-api/core"
+	token_dtos "github.com/keyloom/web-api/dtos/token"
+	"github.com/keyloom/web-api/entities"
+)
+
+var errInvalidClient = errors.New("client authentication failed")
+var errUnauthorizedClient = errors.New("token was issued to another client")
+
+// Authenticates the calling client. Public clients are rejected, they can
+// only identify themselves.
+func authenticateClient(c *gin.Context) (*entities.Application, error) {
+	application, err := identifyClient(c)
+	if err != nil {
+		return nil, err
+	}
+	if application.IsPublic() {
+		return nil, errInvalidClient
+	}
+	return application, nil
+}
+
+// Identifies the client of a request using the token endpoint authentication
+// method it is registered with: HTTP Basic authentication
+// (client_secret_basic), the client_secret form parameter
+// (client_secret_post), a signed JWT (private_key_jwt, client_secret_jwt),
+// its TLS client certificate (tls_client_auth, self_signed_tls_client_auth),
+// or only its client_id for public clients (none).
+func identifyClient(c *gin.Context) (*entities.Application, error) {
+	if c.PostForm("client_assertion_type") != "" || c.PostForm("client_assertion") != "" {
+		return assertedClient(c)
+	}
+
+	clientID, clientSecret, method, err := clientCredentials(c)
+	if err != nil {
+		return nil, err
+	}
+
+	application := (&entities.Application{}).LoadByClientID(clientID)
+	if application == nil {
+		return nil, errInvalidClient
+	}
+	// mutual-TLS clients only send their client_id along with the certificate
+	if application.UsesMutualTLS() {
+		if method != core.NoneAuthMethod || !application.CheckClientCertificate(clientCertificates(c)) {
+			return nil, errInvalidClient
+		}
+		return application, nil
+	}
+	// the client must use the method it registered, which also keeps public
+	// clients from presenting secrets and confidential ones from omitting them
+	if method != application.TokenEndpointAuthMethod {
+		return nil, errInvalidClient
+	}
+	if method != core.NoneAuthMethod && !application.CheckClientSecret(clientSecret) {
+		return nil, errInvalidClient
+	}
+	return application, nil
+}
+
+// Reads the client credentials of the request and the authentication method
+// they were presented with
+func clientCredentials(c *gin.Context) (string, string, string, error) {
+	clientID, clientSecret, ok := c.Request.BasicAuth()
+	if ok {
+		// RFC 6749 section 2.3.1 requires the credentials to be form-encoded
+		var err error
+		if clientID, err = url.QueryUnescape(clientID); err != nil {
+			return "", "", "", errInvalidClient
+		}
+		if clientSecret, err = url.QueryUnescape(clientSecret); err != nil {
+			return "", "", "", errInvalidClient
+		}
+		// a client_id parameter, when also sent, must name the same client
+		if formClientID := c.PostForm("client_id"); formClientID != "" && formClientID != clientID {
+			return "", "", "", errInvalidClient
+		}
+		if clientID == "" || clientSecret == "" {
+			return "", "", "", errInvalidClient
+		}
+		return clientID, clientSecret, core.ClientSecretBasicAuthMethod, nil
+	}
+
+	clientID = c.PostForm("client_id")
+	if clientID == "" {
+		return "", "", "", errInvalidClient
+	}
+	if clientSecret = c.PostForm("client_secret"); clientSecret != "" {
+		return clientID, clientSecret, core.ClientSecretPostAuthMethod, nil
+	}
+	return clientID, "", core.NoneAuthMethod, nil
+}
+
+// Authenticates a client with a JWT it signed (RFC 7523 section 2.2), with
+// its private key or an HMAC of its client secret
+func assertedClient(c *gin.Context) (*entities.Application, error) {
+	assertion := c.PostForm("client_assertion")
+	if c.PostForm("client_assertion_type") != core.ClientAssertionTypeJWTBearer || assertion == "" {
+		return nil, errInvalidClient
+	}
+	// a client must use exactly one authentication method
+	if _, _, ok := c.Request.BasicAuth(); ok || c.PostForm("client_secret") != "" {
+		return nil, errInvalidClient
+	}
+
+	clientID := c.PostForm("client_id")
+	if clientID == "" {
+		// the verification below makes sure the client issued the assertion
+		issuer, err := core.ClientAssertionIssuer(assertion)
+		if err != nil || issuer == "" {
+			return nil, errInvalidClient
+		}
+		clientID = issuer
+	}
+	application := (&entities.Application{}).LoadByClientID(clientID)
+	if application == nil {
+		return nil, errInvalidClient
+	}
+
+	verifier := &core.ClientAssertionVerifier{
+		Keys:   application.AssertionKeys,
+		Replay: &entities.ClientAssertion{},
+	}
+	switch application.TokenEndpointAuthMethod {
+	case core.PrivateKeyJWTAuthMethod:
+		verifier.Algorithms = core.ClientAssertionAsymmetricAlgorithms
+	case core.ClientSecretJWTAuthMethod:
+		verifier.Algorithms = core.ClientAssertionHMACAlgorithms
+	default:
+		return nil, errInvalidClient
+	}
+	audiences, err := assertionAudiences(c)
+	if err != nil {
+		return nil, err
+	}
+	verifier.Audiences = audiences
+	if err := verifier.Verify(assertion, clientID); err != nil {
+		return nil, errInvalidClient
+	}
+	return application, nil
+}
+
+// Returns the values identifying this server in the aud claim of client
+// assertions: the issuer, the token endpoint and the endpoint being called
+func assertionAudiences(c *gin.Context) ([]string, error) {
+	config, err := (&core.EnvManager{}).GetTokenConfig()
+	if err != nil {
+		return nil, err
+	}
+	baseURL := strings.TrimRight(config.Issuer, "/")
+	return []string{
+		config.Issuer,
+		baseURL + "/token/",
+		baseURL + "/token",
+		baseURL + c.Request.URL.Path,
+	}, nil
+}
+
+// Returns the certificate chain the client presented during the TLS
+// handshake, the client certificate first
+func clientCertificates(c *gin.Context) []*x509.Certificate {
+	if c.Request.TLS == nil {
+		return nil
+	}
+	return c.Request.TLS.PeerCertificates
+}
+
+// Returns the confirmation claim binding tokens to the client certificate,
+// for clients authenticating with mutual TLS or asking for bound tokens, and
+// to the key of the DPoP proof of the request
+func tokenConfirmation(c *gin.Context, application *entities.Application) *token_dtos.Confirmation {
+	confirmation := token_dtos.Confirmation{Jkt: dpopKey(c)}
+	certificates := clientCertificates(c)
+	if (application.UsesMutualTLS() || application.TLSClientCertificateBoundAccessTokens) && len(certificates) > 0 {
+		confirmation.X5tS256 = core.CertificateThumbprint(certificates[0])
+	}
+	if confirmation == (token_dtos.Confirmation{}) {
+		return nil
+	}
+	return &confirmation
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"github.com/keyloom/web-api/core"
+	"github.com/keyloom/web-api/entities"
+)
+
+// A scope as shown to the user on consent pages
+type scopeDescription struct {
+	Value       string
+	Description string
+}
+
+// Describes the scopes using the definitions of the application's resource
+// servers and the built-in OpenID Connect descriptions. Scopes without a
+// definition are shown as is.
+func describeScopes(application *entities.Application, scopes []string) []scopeDescription {
+	descriptions := []scopeDescription{}
+	for _, scope := range scopes {
+		description, ok := core.OpenIDScopeDescriptions[scope]
+		for _, resourceServer := range application.ResourceServers {
+			if ok {
+				break
+			}
+			description, ok = resourceServer.ScopeDescription(scope)
+		}
+		if !ok {
+			description = scope
+		}
+		descriptions = append(descriptions, scopeDescription{Value: scope, Description: description})
+	}
+	return descriptions
+}
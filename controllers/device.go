@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keyloom/web-api/core"
+	device_dtos "github.com/keyloom/web-api/dtos/device"
+	"github.com/keyloom/web-api/entities"
+)
+
+type DeviceController struct{}
+
+var _ core.Controller = (*DeviceController)(nil)
+
+func (dc *DeviceController) RegisterRoutes(engine *gin.Engine) {
+	deviceGroup := engine.Group("/device")
+	{
+		deviceGroup.POST("/authorize", dc.DeviceAuthorizationHandler)
+		deviceGroup.GET("", dc.VerificationPageHandler)
+		deviceGroup.POST("", dc.VerificationHandler)
+	}
+}
+
+// @Summary Device authorization endpoint
+// @Param client_id formData string true "Client ID"
+// @Param client_secret formData string false "Client secret of confidential clients"
+// @Param client_assertion_type formData string false "urn:ietf:params:oauth:client-assertion-type:jwt-bearer for private_key_jwt and client_secret_jwt clients"
+// @Param client_assertion formData string false "JWT authenticating the client"
+// @Param scope formData string false "Space delimited scopes"
+// @Description Issue a device code and a user code for input constrained devices (RFC 8628)
+// @Accept application/x-www-form-urlencoded
+// @Produce json
+// @Success 200 {object} device_dtos.DeviceAuthorizationResponse
+// @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /device/authorize [post]
+// @Tags Device
+func (dc *DeviceController) DeviceAuthorizationHandler(c *gin.Context) {
+	application, err := identifyClient(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client", "error_description": err.Error()})
+		return
+	}
+	if !application.AllowsGrantType(core.DeviceCodeGrant) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unauthorized_client", "error_description": "the client may not use the device code grant"})
+		return
+	}
+	config, err := (&core.EnvManager{}).GetTokenConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "invalid token configuration"})
+		return
+	}
+
+	deviceCode := (&entities.DeviceCode{}).CreateNew()
+	deviceCode.ClientID = application.ClientID
+	// the user consents to the scopes on approval
+	deviceCode.Scopes = core.EffectiveScopes(core.ParseScopes(c.PostForm("scope")), application.Scopes, application.Scopes)
+	if len(deviceCode.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope", "error_description": "none of the requested scopes are allowed"})
+		return
+	}
+	code, err := deviceCode.Issue()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "failed to issue device code"})
+		return
+	}
+
+	verificationURI := strings.TrimRight(config.Issuer, "/") + "/device"
+	c.JSON(http.StatusOK, device_dtos.DeviceAuthorizationResponse{
+		DeviceCode:              code,
+		UserCode:                deviceCode.UserCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?user_code=" + url.QueryEscape(deviceCode.UserCode),
+		ExpiresIn:               int64(time.Until(deviceCode.ExpireAt).Seconds()),
+		Interval:                deviceCode.Interval,
+	})
+}
+
+// @Summary Device verification page
+// @Param user_code query string false "User code displayed on the device"
+// @Description Render the page where the user enters the code of a device and approves it
+// @Produce html
+// @Success 200 {string} string "Verification page"
+// @Router /device [get]
+// @Tags Device
+func (dc *DeviceController) VerificationPageHandler(c *gin.Context) {
+	userCode := c.Query("user_code")
+	if userCode == "" {
+		c.HTML(http.StatusOK, "device.html", gin.H{})
+		return
+	}
+
+	// show which application is asking before the user signs in
+	deviceCode := (&entities.DeviceCode{}).LoadPendingByUserCode(userCode)
+	if deviceCode == nil {
+		c.HTML(http.StatusNotFound, "device.html", gin.H{"UserCode": userCode, "Error": "Invalid or expired code"})
+		return
+	}
+	application := (&entities.Application{}).LoadByClientID(deviceCode.ClientID)
+	if application == nil {
+		c.HTML(http.StatusNotFound, "device.html", gin.H{"UserCode": userCode, "Error": "Invalid or expired code"})
+		return
+	}
+	c.HTML(http.StatusOK, "device.html", gin.H{
+		"ApplicationName": application.Name,
+		"UserCode":        deviceCode.UserCode,
+		"Scopes":          describeScopes(application, deviceCode.Scopes),
+	})
+}
+
+// @Summary Approve or deny a device
+// @Param user_code formData string true "User code displayed on the device"
+// @Param username formData string true "User email"
+// @Param password formData string true "User password"
+// @Param action formData string true "approve or deny"
+// @Description Authenticate the user and record their decision for the device
+// @Accept application/x-www-form-urlencoded
+// @Produce html
+// @Success 200 {string} string "Result page"
+// @Failure 400 {string} string "Verification page with an error"
+// @Failure 401 {string} string "Verification page with an error"
+// @Failure 403 {string} string "Verification page, the user is disabled"
+// @Router /device [post]
+// @Tags Device
+func (dc *DeviceController) VerificationHandler(c *gin.Context) {
+	var dto device_dtos.DeviceVerificationDTO
+	if err := c.ShouldBind(&dto); err != nil {
+		c.HTML(http.StatusBadRequest, "device.html", gin.H{"UserCode": dto.UserCode, "Error": "Invalid request"})
+		return
+	}
+
+	deviceCode := (&entities.DeviceCode{}).LoadPendingByUserCode(dto.UserCode)
+	if deviceCode == nil {
+		c.HTML(http.StatusBadRequest, "device.html", gin.H{"Error": "Invalid or expired code"})
+		return
+	}
+	application := (&entities.Application{}).LoadByClientID(deviceCode.ClientID)
+	if application == nil {
+		c.HTML(http.StatusBadRequest, "device.html", gin.H{"Error": "Invalid or expired code"})
+		return
+	}
+
+	// verify user credentials
+	user := (&entities.User{}).LoadByEmail(dto.Username)
+	if user == nil || !user.CheckPassword(dto.Password) {
+		c.HTML(http.StatusUnauthorized, "device.html", gin.H{
+			"ApplicationName": application.Name,
+			"UserCode":        deviceCode.UserCode,
+			"Scopes":          describeScopes(application, deviceCode.Scopes),
+			"Error":           "Invalid email or password",
+		})
+		return
+	}
+	if user.IsDisabled() {
+		c.HTML(http.StatusForbidden, "device.html", gin.H{
+			"ApplicationName": application.Name,
+			"UserCode":        deviceCode.UserCode,
+			"Scopes":          describeScopes(application, deviceCode.Scopes),
+			"Error":           "This account is disabled",
+		})
+		return
+	}
+
+	status := core.DeviceCodeStatusDenied
+	message := "Access was denied. You can close this page."
+	if dto.Action == "approve" {
+		// approving the device is consenting to its scopes
+		_, err := (&entities.Grant{}).Consent(user.ID, application.ID, deviceCode.Scopes)
+		if err != nil {
+			c.HTML(http.StatusInternalServerError, "device.html", gin.H{"Error": "Failed to save your decision, please try again"})
+			return
+		}
+		status = core.DeviceCodeStatusApproved
+		message = "Your device is now connected. You can close this page and return to it."
+	}
+	// only the first decision counts when the code is submitted twice
+	decided, err := deviceCode.Decide(status, user.ID)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "device.html", gin.H{"Error": "Failed to save your decision, please try again"})
+		return
+	}
+	if !decided {
+		c.HTML(http.StatusBadRequest, "device.html", gin.H{"Error": "Invalid or expired code"})
+		return
+	}
+	c.HTML(http.StatusOK, "device.html", gin.H{"Message": message})
+}
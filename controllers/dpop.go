@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keyloom/web-api/core"
+	"github.com/keyloom/web-api/entities"
+)
+
+// Context key of the JWK thumbprint of a verified DPoP proof
+const dpopKeyContextKey = "dpop_jkt"
+
+// Returns a DPoP verifier backed by the persisted replay cache
+func newDPoPVerifier(requireNonce bool) *core.DPoPVerifier {
+	return &core.DPoPVerifier{
+		Replay:       &entities.DPoPProof{},
+		RequireNonce: requireNonce,
+	}
+}
+
+// Returns the URL of the current request as clients address it, relative
+// to the issuer since TLS may be terminated in front of Keyloom
+func requestURL(c *gin.Context) (string, error) {
+	config, err := (&core.EnvManager{}).GetTokenConfig()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(config.Issuer, "/") + c.Request.URL.Path, nil
+}
+
+// Verifies the DPoP proof of a token request, if any, and keeps the key
+// thumbprint for the issued tokens. Proofs must carry a nonce issued by
+// Keyloom, which is returned in the DPoP-Nonce header. Writes the error
+// response and returns false when the request must be rejected.
+func verifyTokenRequestDPoP(c *gin.Context, application *entities.Application) bool {
+	proofs := c.Request.Header.Values("DPoP")
+	if len(proofs) == 0 {
+		if application.DPoPBoundAccessTokens {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_dpop_proof", "error_description": "a DPoP proof is required"})
+			return false
+		}
+		return true
+	}
+
+	nonce, err := core.NewDPoPNonce()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "invalid token configuration"})
+		return false
+	}
+	c.Header("DPoP-Nonce", nonce)
+	if len(proofs) > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_dpop_proof", "error_description": "only one DPoP proof is allowed"})
+		return false
+	}
+	target, err := requestURL(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "invalid token configuration"})
+		return false
+	}
+
+	thumbprint, err := newDPoPVerifier(true).VerifyProof(proofs[0], c.Request.Method, target, "")
+	if errors.Is(err, core.ErrUseDPoPNonce) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "use_dpop_nonce", "error_description": err.Error()})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_dpop_proof", "error_description": err.Error()})
+		return false
+	}
+	c.Set(dpopKeyContextKey, thumbprint)
+	return true
+}
+
+// Returns the JWK thumbprint of the DPoP proof of the request, if any
+func dpopKey(c *gin.Context) string {
+	return c.GetString(dpopKeyContextKey)
+}
+
+// Returns the DPoP key refresh tokens must be bound to. Only refresh tokens
+// of public clients are bound, those of confidential clients are already
+// bound to the client authentication (RFC 9449 section 5).
+func refreshTokenDPoPKey(c *gin.Context, application *entities.Application) string {
+	if !application.IsPublic() {
+		return ""
+	}
+	return dpopKey(c)
+}
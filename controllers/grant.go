@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keyloom/web-api/core"
+	grant_dtos "github.com/keyloom/web-api/dtos/grant"
+	"github.com/keyloom/web-api/entities"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type GrantController struct{}
+
+var _ core.Controller = (*GrantController)(nil)
+
+func (gc *GrantController) RegisterRoutes(engine *gin.Engine) {
+	grantGroup := engine.Group("/grants")
+	{
+		grantGroup.POST("/", requireScopes(core.ManageGrantsScope), gc.CreateHandler)
+		grantGroup.GET("/", requireScopes(core.ViewGrantsScope), gc.GetAllHandler)
+		grantGroup.GET("/:id", requireScopes(core.ViewGrantsScope), gc.GetByIDHandler)
+		grantGroup.PUT("/:id", requireScopes(core.ManageGrantsScope), gc.UpdateHandler)
+		grantGroup.DELETE("/:id", requireScopes(core.ManageGrantsScope), gc.DeleteHandler)
+	}
+}
+
+// @Summary Create a new grant
+// @Param body body grant_dtos.CreateGrantDTO true "Grant creation data"
+// @Description Allow an application to use scopes on behalf of a user, as if the user consented. The scopes must be allowed for the application, a user has at most one grant per application.
+// @Accept json
+// @Produce json
+// @Success 201 {object} entities.Grant
+// @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
+// @Failure 404 {object} interface{}
+// @Failure 409 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /grants/ [post]
+// @Tags Grants
+// @Security ApiKeyAuth
+func (gc *GrantController) CreateHandler(c *gin.Context) {
+	var dto grant_dtos.CreateGrantDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	user := (&entities.User{}).LoadByID(dto.UserID)
+	if user == nil {
+		c.JSON(404, gin.H{"error": "User not found"})
+		return
+	}
+	application := (&entities.Application{}).LoadByID(dto.ApplicationID)
+	if application == nil {
+		c.JSON(404, gin.H{"error": "Application not found"})
+		return
+	}
+	if (&entities.Grant{}).LoadByUserAndApplication(user.ID, application.ID) != nil {
+		c.JSON(409, gin.H{"error": "The user already has a grant for this application"})
+		return
+	}
+	if scope, ok := grantableScopes(application, dto.Scopes); !ok {
+		c.JSON(400, gin.H{"error": "Scope is not allowed for the application: " + scope})
+		return
+	}
+
+	grant := (&entities.Grant{}).CreateNew()
+	grant.UserID = user.ID
+	grant.ApplicationID = application.ID
+	grant.Scopes = dto.Scopes
+	err := grant.Save()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create grant"})
+		return
+	}
+	grant.User = user
+	grant.Application = application
+	c.JSON(201, grant)
+}
+
+// @Summary Get all grants with pagination
+// @Param limit query int false "Number of grants to return" default(10)
+// @Param page query int false "Page number" default(1)
+// @Param user_id query string false "Only the grants of this user"
+// @Param application_id query string false "Only the grants to this application"
+// @Description Retrieve a paginated list of grants, newest first, with their user and application
+// @Produce json
+// @Success 200 {array} entities.Grant
+// @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /grants/ [get]
+// @Tags Grants
+// @Security ApiKeyAuth
+func (gc *GrantController) GetAllHandler(c *gin.Context) {
+	var filter grant_dtos.GrantFilterDTO
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := optionalObjectID(filter.UserID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user_id"})
+		return
+	}
+	applicationID, err := optionalObjectID(filter.ApplicationID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid application_id"})
+		return
+	}
+	top, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || top <= 0 {
+		top = 10
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	grants := (&entities.Grant{}).LoadFiltered(userID, applicationID, top, page)
+	if grants == nil {
+		c.JSON(500, gin.H{"error": "Failed to load grants"})
+		return
+	}
+	c.JSON(200, grants)
+}
+
+// @Summary Get grant by ID
+// @Param id path string true "Grant ID"
+// @Description Retrieve a grant by its ID, with its user and application
+// @Produce json
+// @Success 200 {object} entities.Grant
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
+// @Failure 404 {object} interface{}
+// @Router /grants/{id} [get]
+// @Tags Grants
+// @Security ApiKeyAuth
+func (gc *GrantController) GetByIDHandler(c *gin.Context) {
+	grant := (&entities.Grant{}).LoadByID(c.Param("id"))
+	if grant == nil {
+		c.JSON(404, gin.H{"error": "Grant not found"})
+		return
+	}
+	c.JSON(200, grant)
+}
+
+// @Summary Update an existing grant
+// @Param id path string true "Grant ID"
+// @Param body body grant_dtos.UpdateGrantDTO true "Grant update data"
+// @Description Replace the scopes of a grant. Scopes that are removed can no longer be obtained through refresh tokens, access tokens already issued keep them until they expire.
+// @Accept json
+// @Produce json
+// @Success 200 {object} entities.Grant
+// @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
+// @Failure 404 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /grants/{id} [put]
+// @Tags Grants
+// @Security ApiKeyAuth
+func (gc *GrantController) UpdateHandler(c *gin.Context) {
+	var dto grant_dtos.UpdateGrantDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	grant := (&entities.Grant{}).LoadByID(c.Param("id"))
+	if grant == nil {
+		c.JSON(404, gin.H{"error": "Grant not found"})
+		return
+	}
+	if grant.Application == nil {
+		c.JSON(400, gin.H{"error": "The application of the grant no longer exists"})
+		return
+	}
+	if scope, ok := grantableScopes(grant.Application, dto.Scopes); !ok {
+		c.JSON(400, gin.H{"error": "Scope is not allowed for the application: " + scope})
+		return
+	}
+
+	grant.Scopes = dto.Scopes
+	err := grant.Save()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to update grant"})
+		return
+	}
+	c.JSON(200, grant)
+}
+
+// @Summary Revoke a grant
+// @Param id path string true "Grant ID"
+// @Description Delete a grant. The refresh tokens and access tokens issued to the application on behalf of the user are revoked, the user is asked to consent again on their next sign in.
+// @Success 204 "No Content"
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
+// @Failure 404 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /grants/{id} [delete]
+// @Tags Grants
+// @Security ApiKeyAuth
+func (gc *GrantController) DeleteHandler(c *gin.Context) {
+	grant := (&entities.Grant{}).LoadByID(c.Param("id"))
+	if grant == nil {
+		c.JSON(404, gin.H{"error": "Grant not found"})
+		return
+	}
+
+	err := (&entities.RefreshToken{}).RevokeByUserAndApplication(grant.UserID, grant.ApplicationID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to revoke refresh tokens"})
+		return
+	}
+	if grant.Application != nil {
+		err = (&entities.RevokedToken{}).RevokeSubject(grant.UserID.Hex(), grant.Application.ClientID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to revoke access tokens"})
+			return
+		}
+	}
+	err = grant.Delete()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to delete grant"})
+		return
+	}
+	c.Status(204)
+}
+
+// Checks that the application may use every scope, OpenID Connect scopes are
+// always allowed. Returns the first scope that is not allowed.
+func grantableScopes(application *entities.Application, scopes []string) (string, bool) {
+	for _, scope := range scopes {
+		if !slices.Contains(application.Scopes, scope) && !slices.Contains(core.OpenIDScopes, scope) {
+			return scope, false
+		}
+	}
+	return "", true
+}
+
+// Parses an optional ObjectID query parameter, empty values give the nil ID
+func optionalObjectID(value string) (primitive.ObjectID, error) {
+	if value == "" {
+		return primitive.NilObjectID, nil
+	}
+	return primitive.ObjectIDFromHex(value)
+}
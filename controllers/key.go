@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keyloom/web-api/core"
+	jwk_dtos "github.com/keyloom/web-api/dtos/jwk"
+	"github.com/keyloom/web-api/entities"
+)
+
+// How often the signing keys are checked for rotation
+var keyRotationCheckInterval = 1 * time.Hour
+
+type KeyController struct{}
+
+var _ core.Controller = (*KeyController)(nil)
+
+func (kc *KeyController) RegisterRoutes(engine *gin.Engine) {
+	wellKnownGroup := engine.Group("/.well-known")
+	{
+		wellKnownGroup.GET("/jwks.json", kc.JWKSHandler)
+	}
+}
+
+// Rotates the signing keys once, so a key is available before the server
+// starts, then keeps checking for rotation in the background
+func (kc *KeyController) StartKeyRotation() {
+	kc.RotateKeys()
+	go func() {
+		ticker := time.NewTicker(keyRotationCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			kc.RotateKeys()
+		}
+	}()
+}
+
+func (kc *KeyController) RotateKeys() {
+	signingKeyConfig, err := (&core.EnvManager{}).GetSigningKeyConfig()
+	if err != nil {
+		fmt.Printf("[KEYS] Invalid signing key configuration: %v\n", err)
+		return
+	}
+	tokenConfig, err := (&core.EnvManager{}).GetTokenConfig()
+	if err != nil {
+		fmt.Printf("[KEYS] Invalid token configuration: %v\n", err)
+		return
+	}
+
+	// retired keys must outlive every token they signed
+	interval := time.Duration(signingKeyConfig.RotationInterval) * time.Minute
+	retention := time.Duration(tokenConfig.TokenDuration) * time.Minute
+	err = (&entities.SigningKey{}).Rotate(signingKeyConfig.Algorithm, interval, retention)
+	if err != nil {
+		fmt.Printf("[KEYS] Signing key rotation failed: %v\n", err)
+	}
+}
+
+// @Summary JSON Web Key Set
+// @Description Public keys used to verify the tokens issued by Keyloom
+// @Produce json
+// @Success 200 {object} jwk_dtos.JWKSet
+// @Router /.well-known/jwks.json [get]
+// @Tags Keys
+func (kc *KeyController) JWKSHandler(c *gin.Context) {
+	keySet := jwk_dtos.JWKSet{Keys: []jwk_dtos.JWK{}}
+	for _, signingKey := range (&entities.SigningKey{}).LoadPublished() {
+		publicKey, err := signingKey.ParsePublicKey()
+		if err != nil {
+			continue
+		}
+		jwk, err := core.NewJWK(publicKey, signingKey.Kid, signingKey.Algorithm)
+		if err != nil {
+			continue
+		}
+		keySet.Keys = append(keySet.Keys, jwk)
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, keySet)
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"fmt"
+	"log"
 	"slices"
 
 	"github.com/keyloom/web-api/core"
@@ -76,6 +77,158 @@ func (mc *MigrationController) RunMigrations() {
 		fmt.Println("[MIGRATIONS] Default grant created.")
 	}
 
+	// Create expiry indexes for the authorization flow if not exists
+	if !slices.Contains(latestMigration.Changes, core.MigrationChangeCreateAuthorizationIndexes) {
+		fmt.Println("[MIGRATIONS] Creating authorization flow indexes...")
+		err := (&entities.AuthorizationRequest{}).CreateIndexes()
+		if err != nil {
+			return
+		}
+		err = (&entities.AuthorizationCode{}).CreateIndexes()
+		if err != nil {
+			return
+		}
+		latestMigration.Changes = append(latestMigration.Changes, core.MigrationChangeCreateAuthorizationIndexes)
+		fmt.Println("[MIGRATIONS] Authorization flow indexes created.")
+	}
+
+	// Create expiry indexes for refresh tokens if not exists
+	if !slices.Contains(latestMigration.Changes, core.MigrationChangeCreateRefreshTokenIndexes) {
+		fmt.Println("[MIGRATIONS] Creating refresh token indexes...")
+		err := (&entities.RefreshToken{}).CreateIndexes()
+		if err != nil {
+			return
+		}
+		latestMigration.Changes = append(latestMigration.Changes, core.MigrationChangeCreateRefreshTokenIndexes)
+		fmt.Println("[MIGRATIONS] Refresh token indexes created.")
+	}
+
+	// Create expiry indexes for the token denylist if not exists
+	if !slices.Contains(latestMigration.Changes, core.MigrationChangeCreateRevokedTokenIndexes) {
+		fmt.Println("[MIGRATIONS] Creating revoked token indexes...")
+		err := (&entities.RevokedToken{}).CreateIndexes()
+		if err != nil {
+			return
+		}
+		latestMigration.Changes = append(latestMigration.Changes, core.MigrationChangeCreateRevokedTokenIndexes)
+		fmt.Println("[MIGRATIONS] Revoked token indexes created.")
+	}
+
+	// Create expiry indexes for device authorizations if not exists
+	if !slices.Contains(latestMigration.Changes, core.MigrationChangeCreateDeviceCodeIndexes) {
+		fmt.Println("[MIGRATIONS] Creating device code indexes...")
+		err := (&entities.DeviceCode{}).CreateIndexes()
+		if err != nil {
+			return
+		}
+		latestMigration.Changes = append(latestMigration.Changes, core.MigrationChangeCreateDeviceCodeIndexes)
+		fmt.Println("[MIGRATIONS] Device code indexes created.")
+	}
+
+	// Align the default grant with the default application scopes if not done
+	if !slices.Contains(latestMigration.Changes, core.MigrationChangeUpdateDefaultGrantScopes) {
+		fmt.Println("[MIGRATIONS] Updating default grant scopes...")
+		err := (&entities.Grant{}).UpdateDefaultGrantScopes(latestMigration)
+		if err != nil {
+			return
+		}
+		fmt.Println("[MIGRATIONS] Default grant scopes updated.")
+	}
+
+	// Give resource servers unique audience identifiers if not done
+	if !slices.Contains(latestMigration.Changes, core.MigrationChangeMigrateResourceServerIdentifiers) {
+		fmt.Println("[MIGRATIONS] Assigning resource server identifiers...")
+		err := (&entities.ResourceServer{}).MigrateIdentifiers(latestMigration)
+		if err != nil {
+			return
+		}
+		fmt.Println("[MIGRATIONS] Resource server identifiers assigned.")
+	}
+
+	// Describe the scopes of the default resource server if not done
+	if !slices.Contains(latestMigration.Changes, core.MigrationChangeUpdateDefaultResourceServerScopes) {
+		fmt.Println("[MIGRATIONS] Describing default resource server scopes...")
+		err := (&entities.ResourceServer{}).UpdateDefaultResourceServerScopes(latestMigration)
+		if err != nil {
+			return
+		}
+		fmt.Println("[MIGRATIONS] Default resource server scopes described.")
+	}
+
+	// Hash plaintext client secrets if not done
+	if !slices.Contains(latestMigration.Changes, core.MigrationChangeHashClientSecrets) {
+		fmt.Println("[MIGRATIONS] Hashing client secrets...")
+		err := (&entities.Application{}).HashClientSecrets(latestMigration)
+		if err != nil {
+			return
+		}
+		fmt.Println("[MIGRATIONS] Client secrets hashed.")
+	}
+
+	// Give a type to existing applications if not done
+	if !slices.Contains(latestMigration.Changes, core.MigrationChangeSetApplicationTypes) {
+		fmt.Println("[MIGRATIONS] Setting application types...")
+		err := (&entities.Application{}).SetApplicationTypes(latestMigration)
+		if err != nil {
+			return
+		}
+		fmt.Println("[MIGRATIONS] Application types set.")
+	}
+
+	// Create replay protection indexes for client assertions if not exists
+	if !slices.Contains(latestMigration.Changes, core.MigrationChangeCreateClientAssertionIndexes) {
+		fmt.Println("[MIGRATIONS] Creating client assertion indexes...")
+		err := (&entities.ClientAssertion{}).CreateIndexes()
+		if err != nil {
+			return
+		}
+		latestMigration.Changes = append(latestMigration.Changes, core.MigrationChangeCreateClientAssertionIndexes)
+		fmt.Println("[MIGRATIONS] Client assertion indexes created.")
+	}
+
+	// Create replay protection indexes for DPoP proofs if not exists
+	if !slices.Contains(latestMigration.Changes, core.MigrationChangeCreateDPoPProofIndexes) {
+		fmt.Println("[MIGRATIONS] Creating DPoP proof indexes...")
+		err := (&entities.DPoPProof{}).CreateIndexes()
+		if err != nil {
+			return
+		}
+		latestMigration.Changes = append(latestMigration.Changes, core.MigrationChangeCreateDPoPProofIndexes)
+		fmt.Println("[MIGRATIONS] DPoP proof indexes created.")
+	}
+
+	// Create session indexes if not exists
+	if !slices.Contains(latestMigration.Changes, core.MigrationChangeCreateSessionIndexes) {
+		fmt.Println("[MIGRATIONS] Creating session indexes...")
+		err := (&entities.Session{}).CreateIndexes()
+		if err != nil {
+			return
+		}
+		latestMigration.Changes = append(latestMigration.Changes, core.MigrationChangeCreateSessionIndexes)
+		fmt.Println("[MIGRATIONS] Session indexes created.")
+	}
+
+	// Create expiry indexes for logout deliveries if not exists
+	if !slices.Contains(latestMigration.Changes, core.MigrationChangeCreateLogoutDeliveryIndexes) {
+		fmt.Println("[MIGRATIONS] Creating logout delivery indexes...")
+		err := (&entities.LogoutDelivery{}).CreateIndexes()
+		if err != nil {
+			return
+		}
+		latestMigration.Changes = append(latestMigration.Changes, core.MigrationChangeCreateLogoutDeliveryIndexes)
+		fmt.Println("[MIGRATIONS] Logout delivery indexes created.")
+	}
+
+	// Activate the users created before user statuses existed
+	if !slices.Contains(latestMigration.Changes, core.MigrationChangeSetUserStatuses) {
+		fmt.Println("[MIGRATIONS] Setting user statuses...")
+		err := (&entities.User{}).SetDefaultStatuses(latestMigration)
+		if err != nil {
+			return
+		}
+		fmt.Println("[MIGRATIONS] User statuses set.")
+	}
+
 	fmt.Println("")
 	fmt.Println("[MIGRATIONS] Migrations completed.")
 	// Save latest migration
@@ -84,3 +237,17 @@ func (mc *MigrationController) RunMigrations() {
 	fmt.Printf("[MIGRATIONS] Latest migration ID: %s\n", latestMigration.ID.Hex())
 	fmt.Println("")
 }
+
+// Creates the indexes security checks rely on. They are ensured on every
+// start rather than once by a migration, so that an earlier failed migration
+// cannot leave replay protection without its unique index.
+func (mc *MigrationController) EnsureIndexes() {
+	err := (&entities.ClientAssertion{}).CreateIndexes()
+	if err != nil {
+		log.Fatalf("[MIGRATIONS] Failed to create client assertion indexes: %v", err)
+	}
+	err = (&entities.DPoPProof{}).CreateIndexes()
+	if err != nil {
+		log.Fatalf("[MIGRATIONS] Failed to create DPoP proof indexes: %v", err)
+	}
+}
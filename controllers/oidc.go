@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keyloom/web-api/core"
+	oidc_dtos "github.com/keyloom/web-api/dtos/oidc"
+	"github.com/keyloom/web-api/entities"
+)
+
+type OIDCController struct {
+	engine *gin.Engine
+}
+
+var _ core.Controller = (*OIDCController)(nil)
+
+func (oc *OIDCController) RegisterRoutes(engine *gin.Engine) {
+	// kept to advertise only the endpoints that are actually registered
+	oc.engine = engine
+
+	wellKnownGroup := engine.Group("/.well-known")
+	{
+		wellKnownGroup.GET("/openid-configuration", oc.DiscoveryHandler)
+	}
+	userInfoGroup := engine.Group("/userinfo")
+	{
+		userInfoGroup.GET("", oc.UserInfoHandler)
+		userInfoGroup.POST("", oc.UserInfoHandler)
+	}
+}
+
+// @Summary OpenID Connect discovery document
+// @Description OpenID Provider metadata derived from the configured issuer and the registered routes
+// @Produce json
+// @Success 200 {object} oidc_dtos.OpenIDConfiguration
+// @Failure 500 {object} interface{}
+// @Router /.well-known/openid-configuration [get]
+// @Tags OpenID Connect
+func (oc *OIDCController) DiscoveryHandler(c *gin.Context) {
+	config, err := (&core.EnvManager{}).GetTokenConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid token configuration"})
+		return
+	}
+	// the issuer must match the iss claim exactly, endpoints are relative to it
+	baseURL := strings.TrimRight(config.Issuer, "/")
+	// certificate-bound tokens need the server to accept client certificates
+	tlsConfig, err := (&core.EnvManager{}).GetTLSConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid TLS configuration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, oidc_dtos.OpenIDConfiguration{
+		Issuer:                                     config.Issuer,
+		AuthorizationEndpoint:                      oc.endpoint(baseURL, http.MethodGet, "/authorize"),
+		TokenEndpoint:                              oc.endpoint(baseURL, http.MethodPost, "/token/"),
+		UserInfoEndpoint:                           oc.endpoint(baseURL, http.MethodGet, "/userinfo"),
+		JWKSURI:                                    oc.endpoint(baseURL, http.MethodGet, "/.well-known/jwks.json"),
+		IntrospectionEndpoint:                      oc.endpoint(baseURL, http.MethodPost, "/token/introspect"),
+		RevocationEndpoint:                         oc.endpoint(baseURL, http.MethodPost, "/token/revoke"),
+		DeviceAuthorizationEndpoint:                oc.endpoint(baseURL, http.MethodPost, "/device/authorize"),
+		RegistrationEndpoint:                       oc.endpoint(baseURL, http.MethodPost, "/register"),
+		PushedAuthorizationRequestEndpoint:         oc.endpoint(baseURL, http.MethodPost, "/par"),
+		EndSessionEndpoint:                         oc.endpoint(baseURL, http.MethodGet, "/logout"),
+		ScopesSupported:                            core.OpenIDScopes,
+		ResponseTypesSupported:                     []string{core.CodeResponseType},
+		GrantTypesSupported:                        core.GrantTypes,
+		SubjectTypesSupported:                      []string{"public"},
+		IDTokenSigningAlgValuesSupported:           core.SigningAlgorithms,
+		TokenEndpointAuthMethodsSupported:          core.TokenEndpointAuthMethods,
+		TokenEndpointAuthSigningAlgValuesSupported: slices.Concat(core.ClientAssertionAsymmetricAlgorithms, core.ClientAssertionHMACAlgorithms),
+		ClaimsSupported: []string{
+			"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "at_hash", "sid",
+			"email", "email_verified", "name", "given_name", "family_name", "updated_at",
+		},
+		CodeChallengeMethodsSupported:          []string{core.CodeChallengeMethodS256},
+		TLSClientCertificateBoundAccessTokens:  tlsConfig.CertFile != "",
+		DPoPSigningAlgValuesSupported:          core.DPoPAlgorithms,
+		RequirePushedAuthorizationRequests:     false,
+		RequestParameterSupported:              true,
+		RequestURIParameterSupported:           true,
+		RequireRequestURIRegistration:          true,
+		RequestObjectSigningAlgValuesSupported: append(slices.Clone(core.RequestObjectAlgorithms), "none"),
+		BackchannelLogoutSupported:             true,
+		BackchannelLogoutSessionSupported:      true,
+		FrontchannelLogoutSupported:            true,
+		FrontchannelLogoutSessionSupported:     true,
+	})
+}
+
+// Returns the absolute URL of the route, or an empty string if it is not registered
+func (oc *OIDCController) endpoint(baseURL, method, path string) string {
+	if oc.engine == nil {
+		return ""
+	}
+	registered := slices.ContainsFunc(oc.engine.Routes(), func(route gin.RouteInfo) bool {
+		return route.Method == method && route.Path == path
+	})
+	if !registered {
+		return ""
+	}
+	return baseURL + path
+}
+
+// @Summary OpenID Connect UserInfo endpoint
+// @Description Claims about the authenticated user, filtered by the profile and email scopes of the access token. DPoP-bound tokens require the DPoP scheme and a proof.
+// @Param DPoP header string false "DPoP proof for DPoP-bound access tokens"
+// @Produce json
+// @Success 200 {object} oidc_dtos.UserInfoResponse
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
+// @Router /userinfo [get]
+// @Tags OpenID Connect
+// @Security ApiKeyAuth
+func (oc *OIDCController) UserInfoHandler(c *gin.Context) {
+	payload, ok := validateAccessToken(c)
+	if !ok {
+		return
+	}
+
+	scopes := core.ParseScopes(payload.Scope)
+	if !slices.Contains(scopes, core.OpenIDScope) {
+		c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient_scope", "error_description": "the openid scope is required"})
+		return
+	}
+
+	user := (&entities.User{}).LoadByID(payload.Sub)
+	if user == nil {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "unknown subject"})
+		return
+	}
+
+	userInfo := oidc_dtos.UserInfoResponse{Sub: user.ID.Hex()}
+	if slices.Contains(scopes, core.EmailScope) {
+		userInfo.Email = user.Email
+		userInfo.EmailVerified = &user.EmailVerified
+	}
+	if slices.Contains(scopes, core.ProfileScope) {
+		userInfo.Name = user.Name
+		userInfo.GivenName = user.GivenName
+		userInfo.FamilyName = user.FamilyName
+		userInfo.UpdatedAt = user.UpdatedAt
+	}
+	c.JSON(http.StatusOK, userInfo)
+}
@@ -0,0 +1,457 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keyloom/web-api/core"
+	registration_dtos "github.com/keyloom/web-api/dtos/registration"
+	"github.com/keyloom/web-api/entities"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RegistrationController struct{}
+
+var _ core.Controller = (*RegistrationController)(nil)
+
+func (rc *RegistrationController) RegisterRoutes(engine *gin.Engine) {
+	registerGroup := engine.Group("/register")
+	{
+		registerGroup.POST("", rc.RegisterHandler)
+		registerGroup.GET("/:client_id", rc.ReadHandler)
+		registerGroup.PUT("/:client_id", rc.UpdateHandler)
+		registerGroup.DELETE("/:client_id", rc.DeleteHandler)
+	}
+	if (&core.EnvManager{}).GetRegistrationConfig().InitialAccessToken == "" {
+		fmt.Printf("[REGISTRATION] REGISTRATION_INITIAL_ACCESS_TOKEN is not set, anyone can register clients\n")
+	}
+}
+
+// @Summary Dynamic client registration endpoint
+// @Param body body registration_dtos.ClientMetadata true "Client metadata"
+// @Description Register an OAuth client (RFC 7591). An initial access token is required when REGISTRATION_INITIAL_ACCESS_TOKEN is configured, the password grant type cannot be registered. The jwks_uri, request_uris and backchannel_logout_uri must use https and public addresses unless REGISTRATION_ALLOW_PRIVATE_URLS is set.
+// @Accept json
+// @Produce json
+// @Success 201 {object} registration_dtos.ClientInformationResponse
+// @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /register [post]
+// @Tags Registration
+// @Security ApiKeyAuth
+func (rc *RegistrationController) RegisterHandler(c *gin.Context) {
+	// registration is restricted to holders of the initial access token when configured
+	initialAccessToken := (&core.EnvManager{}).GetRegistrationConfig().InitialAccessToken
+	if initialAccessToken != "" {
+		token, _ := bearerToken(c)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(initialAccessToken)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "a valid initial access token is required"})
+			return
+		}
+	}
+
+	var metadata registration_dtos.ClientMetadata
+	if err := c.ShouldBindJSON(&metadata); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_client_metadata", "error_description": err.Error()})
+		return
+	}
+	if code, err := validateClientMetadata(&metadata); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": code, "error_description": err.Error()})
+		return
+	}
+
+	application := (&entities.Application{}).CreateNew()
+	application.ClientID = primitive.NewObjectID().Hex()
+	if err := applyClientMetadata(application, metadata); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_client_metadata", "error_description": err.Error()})
+		return
+	}
+	clientSecret, err := rc.ensureClientSecret(application)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "failed to generate the client secret"})
+		return
+	}
+
+	// the registration access token is only returned once
+	registrationAccessToken, err := core.GenerateRandomString(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "failed to generate the registration access token"})
+		return
+	}
+	application.RegistrationAccessToken = (&core.Hasher{}).Digest(registrationAccessToken)
+
+	err = application.Save()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "failed to register the client"})
+		return
+	}
+
+	response, err := clientInformation(application, clientSecret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "invalid token configuration"})
+		return
+	}
+	response.RegistrationAccessToken = registrationAccessToken
+	c.JSON(http.StatusCreated, response)
+}
+
+// @Summary Read a client registration
+// @Param client_id path string true "Client ID"
+// @Description Read the metadata of a dynamically registered client using its registration access token (RFC 7592)
+// @Produce json
+// @Success 200 {object} registration_dtos.ClientInformationResponse
+// @Failure 401 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /register/{client_id} [get]
+// @Tags Registration
+// @Security ApiKeyAuth
+func (rc *RegistrationController) ReadHandler(c *gin.Context) {
+	application, ok := rc.authorizeRegistration(c)
+	if !ok {
+		return
+	}
+	response, err := clientInformation(application, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "invalid token configuration"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Update a client registration
+// @Param client_id path string true "Client ID"
+// @Param body body registration_dtos.ClientUpdateRequest true "Complete client metadata, omitted fields are reset"
+// @Description Replace the metadata of a dynamically registered client using its registration access token (RFC 7592)
+// @Accept json
+// @Produce json
+// @Success 200 {object} registration_dtos.ClientInformationResponse
+// @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /register/{client_id} [put]
+// @Tags Registration
+// @Security ApiKeyAuth
+func (rc *RegistrationController) UpdateHandler(c *gin.Context) {
+	application, ok := rc.authorizeRegistration(c)
+	if !ok {
+		return
+	}
+
+	var request registration_dtos.ClientUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_client_metadata", "error_description": err.Error()})
+		return
+	}
+	if request.ClientID != application.ClientID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_client_metadata", "error_description": "client_id does not match the registration"})
+		return
+	}
+	if code, err := validateClientMetadata(&request.ClientMetadata); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": code, "error_description": err.Error()})
+		return
+	}
+
+	if err := applyClientMetadata(application, request.ClientMetadata); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_client_metadata", "error_description": err.Error()})
+		return
+	}
+	clientSecret, err := rc.ensureClientSecret(application)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "failed to generate the client secret"})
+		return
+	}
+	err = application.Save()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "failed to update the client"})
+		return
+	}
+
+	response, err := clientInformation(application, clientSecret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "invalid token configuration"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Delete a client registration
+// @Param client_id path string true "Client ID"
+// @Description Deprovision a dynamically registered client using its registration access token (RFC 7592)
+// @Success 204 "No Content"
+// @Failure 401 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /register/{client_id} [delete]
+// @Tags Registration
+// @Security ApiKeyAuth
+func (rc *RegistrationController) DeleteHandler(c *gin.Context) {
+	application, ok := rc.authorizeRegistration(c)
+	if !ok {
+		return
+	}
+	err := application.Delete()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "failed to delete the client"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Loads the client of the request path and checks its registration access
+// token. Unknown clients and invalid tokens get the same response.
+func (rc *RegistrationController) authorizeRegistration(c *gin.Context) (*entities.Application, bool) {
+	token, _ := bearerToken(c)
+	application := (&entities.Application{}).LoadByClientID(c.Param("client_id"))
+	if application == nil || !application.CheckRegistrationAccessToken(token) {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "invalid registration access token"})
+		return nil, false
+	}
+	return application, true
+}
+
+// Issues a secret to clients authenticating with one that do not have one
+// yet, and removes the secrets of the other clients. Returns the new
+// plaintext secret.
+func (rc *RegistrationController) ensureClientSecret(application *entities.Application) (string, error) {
+	if !application.UsesClientSecret() {
+		application.ClientSecrets = []entities.ClientSecret{}
+		return "", nil
+	}
+	if len(application.ClientSecrets) > 0 {
+		return "", nil
+	}
+	secret, _, err := application.AddClientSecret("registration", 0)
+	return secret, err
+}
+
+// Validates client metadata and fills in the defaults of RFC 7591 section 2.
+// Returns the RFC 7591 error code along with the error.
+func validateClientMetadata(metadata *registration_dtos.ClientMetadata) (string, error) {
+	if metadata.TokenEndpointAuthMethod == "" {
+		metadata.TokenEndpointAuthMethod = core.ClientSecretBasicAuthMethod
+	}
+	if !slices.Contains(core.TokenEndpointAuthMethods, metadata.TokenEndpointAuthMethod) {
+		return "invalid_client_metadata", errors.New("unsupported token_endpoint_auth_method")
+	}
+
+	if len(metadata.GrantTypes) == 0 {
+		metadata.GrantTypes = []string{core.CodeGrant}
+	}
+	metadata.GrantTypes = slices.Compact(slices.Sorted(slices.Values(metadata.GrantTypes)))
+	for _, grantType := range metadata.GrantTypes {
+		if !slices.Contains(core.GrantTypes, grantType) {
+			return "invalid_client_metadata", errors.New("unsupported grant type: " + grantType)
+		}
+	}
+	// registration may be open, clients must not collect the passwords of users
+	if slices.Contains(metadata.GrantTypes, core.PasswordGrant) {
+		return "invalid_client_metadata", errors.New("the password grant type cannot be registered")
+	}
+	if metadata.ApplicationType == "" {
+		metadata.ApplicationType = core.WebApplicationType
+	}
+	if metadata.ApplicationType != core.WebApplicationType && metadata.ApplicationType != core.NativeApplicationType {
+		return "invalid_client_metadata", errors.New("application_type must be web or native")
+	}
+
+	usesCode := slices.Contains(metadata.GrantTypes, core.CodeGrant)
+	if len(metadata.ResponseTypes) == 0 && usesCode {
+		metadata.ResponseTypes = []string{core.CodeResponseType}
+	}
+	for _, responseType := range metadata.ResponseTypes {
+		if responseType != core.CodeResponseType {
+			return "invalid_client_metadata", errors.New("unsupported response type: " + responseType)
+		}
+	}
+	if usesCode != (len(metadata.ResponseTypes) > 0) {
+		return "invalid_client_metadata", errors.New("the code response type requires the authorization_code grant type and vice versa")
+	}
+
+	if usesCode && len(metadata.RedirectURIs) == 0 {
+		return "invalid_redirect_uri", errors.New("redirect_uris are required for the authorization_code grant type")
+	}
+	for _, redirectURI := range metadata.RedirectURIs {
+		if !isValidRedirectURI(redirectURI) {
+			return "invalid_redirect_uri", errors.New("invalid redirect URI: " + redirectURI)
+		}
+	}
+	for _, redirectURI := range metadata.PostLogoutRedirectURIs {
+		if !isValidRedirectURI(redirectURI) {
+			return "invalid_client_metadata", errors.New("invalid post logout redirect URI: " + redirectURI)
+		}
+	}
+
+	// other scopes give access to resource servers and are assigned by administrators
+	for _, scope := range core.ParseScopes(metadata.Scope) {
+		if !slices.Contains(core.OpenIDScopes, scope) {
+			return "invalid_client_metadata", errors.New("scope cannot be registered: " + scope)
+		}
+	}
+
+	for _, uri := range []string{metadata.ClientURI, metadata.LogoURI} {
+		if uri != "" && !isWebURL(uri) {
+			return "invalid_client_metadata", errors.New("invalid URL: " + uri)
+		}
+	}
+	// Keyloom itself sends requests to these URLs
+	fetchedURIs := append([]string{metadata.JWKSURI, metadata.BackchannelLogoutURI}, metadata.RequestURIs...)
+	for _, uri := range fetchedURIs {
+		if uri != "" && !isFetchableURL(uri) {
+			return "invalid_client_metadata", errors.New("URL must use https: " + uri)
+		}
+	}
+	return "", nil
+}
+
+// Redirect URIs must be absolute and without fragment. Plain HTTP is only
+// allowed for loopback addresses used by native applications.
+func isValidRedirectURI(redirectURI string) bool {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil || !parsed.IsAbs() || parsed.Fragment != "" {
+		return false
+	}
+	if parsed.Scheme == "http" {
+		host := parsed.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	}
+	return true
+}
+
+func isWebURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+}
+
+// URLs Keyloom sends requests to must use HTTPS, unless private URLs are
+// allowed for development. Their addresses are checked when connecting.
+func isFetchableURL(value string) bool {
+	parsed, err := url.Parse(value)
+	if err != nil || !isWebURL(value) {
+		return false
+	}
+	return parsed.Scheme == "https" || (&core.EnvManager{}).GetRegistrationConfig().AllowPrivateURLs
+}
+
+// Copies validated client metadata onto the application. The application
+// type follows from the metadata and must allow the requested grant types.
+func applyClientMetadata(application *entities.Application, metadata registration_dtos.ClientMetadata) error {
+	application.Name = metadata.ClientName
+	if application.Name == "" {
+		application.Name = application.ClientID
+	}
+	application.RedirectURIs = metadata.RedirectURIs
+	if application.RedirectURIs == nil {
+		application.RedirectURIs = []string{}
+	}
+	application.PostLogoutRedirectURIs = metadata.PostLogoutRedirectURIs
+	if application.PostLogoutRedirectURIs == nil {
+		application.PostLogoutRedirectURIs = []string{}
+	}
+	application.ResponseTypes = metadata.ResponseTypes
+	if application.ResponseTypes == nil {
+		application.ResponseTypes = []string{}
+	}
+	application.Scopes = core.ParseScopes(metadata.Scope)
+	application.ClientURI = metadata.ClientURI
+	application.LogoURI = metadata.LogoURI
+	application.Contacts = metadata.Contacts
+	if application.Contacts == nil {
+		application.Contacts = []string{}
+	}
+	err := application.SetType(registeredApplicationType(metadata), metadata.GrantTypes, metadata.TokenEndpointAuthMethod)
+	if err != nil {
+		return err
+	}
+	err = application.SetTLSClientAuth(metadata.TLSClientAuthSubjectDN, metadata.TLSClientCertificateBoundAccessTokens)
+	if err != nil {
+		return err
+	}
+	application.DPoPBoundAccessTokens = metadata.DPoPBoundAccessTokens
+	application.RequirePushedAuthorizationRequests = metadata.RequirePushedAuthorizationRequests
+	err = application.SetJWKS(metadata.JWKS, metadata.JWKSURI)
+	if err != nil {
+		return err
+	}
+	err = application.SetRequestObjects(metadata.RequestURIs, metadata.RequireSignedRequestObject)
+	if err != nil {
+		return err
+	}
+	return application.SetLogoutURIs(metadata.BackchannelLogoutURI, metadata.BackchannelLogoutSessionRequired, metadata.FrontchannelLogoutURI, metadata.FrontchannelLogoutSessionRequired)
+}
+
+// Maps registered metadata to an application type: public clients are SPAs
+// or native applications, confidential clients without user facing grants
+// are machine-to-machine applications
+func registeredApplicationType(metadata registration_dtos.ClientMetadata) string {
+	if metadata.TokenEndpointAuthMethod == core.NoneAuthMethod {
+		if metadata.ApplicationType == core.NativeApplicationType {
+			return core.NativeApplicationType
+		}
+		return core.SPAApplicationType
+	}
+	for _, grantType := range metadata.GrantTypes {
+		if !slices.Contains(core.ApplicationTypeGrantTypes[core.M2MApplicationType], grantType) {
+			return core.WebApplicationType
+		}
+	}
+	return core.M2MApplicationType
+}
+
+// Builds the client information response of the application
+func clientInformation(application *entities.Application, clientSecret string) (registration_dtos.ClientInformationResponse, error) {
+	config, err := (&core.EnvManager{}).GetTokenConfig()
+	if err != nil {
+		return registration_dtos.ClientInformationResponse{}, err
+	}
+	response := registration_dtos.ClientInformationResponse{
+		ClientID:              application.ClientID,
+		ClientIDIssuedAt:      application.CreatedAt,
+		RegistrationClientURI: strings.TrimRight(config.Issuer, "/") + "/register/" + url.PathEscape(application.ClientID),
+		ClientMetadata: registration_dtos.ClientMetadata{
+			ClientName:                            application.Name,
+			ApplicationType:                       registrationApplicationType(application),
+			RedirectURIs:                          application.RedirectURIs,
+			GrantTypes:                            application.GrantTypes,
+			ResponseTypes:                         application.ResponseTypes,
+			TokenEndpointAuthMethod:               application.TokenEndpointAuthMethod,
+			Scope:                                 strings.Join(application.Scopes, " "),
+			ClientURI:                             application.ClientURI,
+			LogoURI:                               application.LogoURI,
+			Contacts:                              application.Contacts,
+			JWKS:                                  application.JWKS,
+			JWKSURI:                               application.JWKSURI,
+			TLSClientAuthSubjectDN:                application.TLSClientAuthSubjectDN,
+			TLSClientCertificateBoundAccessTokens: application.TLSClientCertificateBoundAccessTokens,
+			DPoPBoundAccessTokens:                 application.DPoPBoundAccessTokens,
+			RequirePushedAuthorizationRequests:    application.RequirePushedAuthorizationRequests,
+			RequestURIs:                           application.RequestURIs,
+			RequireSignedRequestObject:            application.RequireSignedRequestObject,
+			PostLogoutRedirectURIs:                application.PostLogoutRedirectURIs,
+			BackchannelLogoutURI:                  application.BackchannelLogoutURI,
+			BackchannelLogoutSessionRequired:      application.BackchannelLogoutSessionRequired,
+			FrontchannelLogoutURI:                 application.FrontchannelLogoutURI,
+			FrontchannelLogoutSessionRequired:     application.FrontchannelLogoutSessionRequired,
+		},
+	}
+	if clientSecret != "" {
+		var expiresAt int64
+		response.ClientSecret = clientSecret
+		response.ClientSecretExpiresAt = &expiresAt
+	}
+	return response, nil
+}
+
+// Maps an application type back to the registration application_type
+func registrationApplicationType(application *entities.Application) string {
+	if application.Type == core.NativeApplicationType {
+		return core.NativeApplicationType
+	}
+	return core.WebApplicationType
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/keyloom/web-api/core"
+	authorize_dtos "github.com/keyloom/web-api/dtos/authorize"
+	"github.com/keyloom/web-api/entities"
+)
+
+// Applies the request object of an authorization request (RFC 9101), passed
+// by value or published at a registered request_uri. Its parameters take
+// precedence over those of the request. Returns the OAuth error code along
+// with the error when the request object is invalid or missing although
+// required.
+func applyRequestObject(application *entities.Application, dto *authorize_dtos.AuthorizationRequestDTO) (string, error) {
+	object := dto.Request
+	if dto.RequestURI != "" {
+		if object != "" {
+			return "invalid_request", errors.New("request and request_uri cannot both be used")
+		}
+		// only registered URIs are fetched, so clients cannot point Keyloom anywhere
+		if !slices.Contains(application.RequestURIs, dto.RequestURI) {
+			return "invalid_request_uri", errors.New("the request_uri is not registered for this client")
+		}
+		var err error
+		object, err = core.FetchRequestObject(dto.RequestURI)
+		if err != nil {
+			return "invalid_request_uri", errors.New("the request object could not be fetched")
+		}
+	}
+	if object == "" {
+		if application.RequireSignedRequestObject {
+			return "invalid_request", errors.New("a signed request object is required")
+		}
+		return "", nil
+	}
+
+	config, err := (&core.EnvManager{}).GetTokenConfig()
+	if err != nil {
+		return "server_error", errors.New("invalid token configuration")
+	}
+	verifier := &core.RequestObjectVerifier{
+		Keys:          application.SigningKeys,
+		RequireSigned: application.RequireSignedRequestObject,
+		Audience:      config.Issuer,
+	}
+	claims, err := verifier.Verify(object, application.ClientID)
+	if err != nil {
+		return "invalid_request_object", err
+	}
+	overlayRequestObject(dto, claims)
+	dto.Request = ""
+	dto.RequestURI = ""
+	return "", nil
+}
+
+// Replaces the parameters of the request with those of the request object
+func overlayRequestObject(dto *authorize_dtos.AuthorizationRequestDTO, claims jwt.MapClaims) {
+	parameters := map[string]*string{
+		"response_type":         &dto.ResponseType,
+		"redirect_uri":          &dto.RedirectURI,
+		"scope":                 &dto.Scope,
+		"state":                 &dto.State,
+		"code_challenge":        &dto.CodeChallenge,
+		"code_challenge_method": &dto.CodeChallengeMethod,
+		"nonce":                 &dto.Nonce,
+		"prompt":                &dto.Prompt,
+	}
+	for name, field := range parameters {
+		if value, ok := claims[name].(string); ok {
+			*field = value
+		}
+	}
+
+	if maxAge, ok := claims["max_age"].(float64); ok && maxAge >= 0 {
+		value := int64(maxAge)
+		dto.MaxAge = &value
+	}
+
+	// resource may be a single indicator or an array of them
+	switch resource := claims["resource"].(type) {
+	case string:
+		dto.Resources = []string{resource}
+	case []interface{}:
+		dto.Resources = []string{}
+		for _, value := range resource {
+			if indicator, ok := value.(string); ok {
+				dto.Resources = append(dto.Resources, indicator)
+			}
+		}
+	}
+}
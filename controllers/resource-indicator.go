@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/keyloom/web-api/core"
+	"github.com/keyloom/web-api/entities"
+)
+
+var errInvalidTarget = errors.New("the requested resource is invalid or not available to the client")
+
+// Resolves the audiences of a token from the requested resource indicators
+// (RFC 8707). Resources must be linked to the application and, when the
+// grant was already limited to some resources, be one of them. Without
+// requested resources every allowed one is used.
+func resolveAudiences(application *entities.Application, requested []string, allowed []string) ([]string, error) {
+	if len(allowed) == 0 {
+		allowed = application.Audiences()
+	}
+	if len(requested) == 0 {
+		return allowed, nil
+	}
+
+	audiences := []string{}
+	for _, resource := range requested {
+		if !core.IsValidResourceIndicator(resource) {
+			return nil, errInvalidTarget
+		}
+		resourceServer := application.LinkedResourceServer(resource)
+		if resourceServer == nil || resourceServer.Identifier != resource || !slices.Contains(allowed, resource) {
+			return nil, errInvalidTarget
+		}
+		if !slices.Contains(audiences, resource) {
+			audiences = append(audiences, resource)
+		}
+	}
+	return audiences, nil
+}
@@ -17,23 +17,27 @@ var _ core.Controller = (*ResourceServerController)(nil)
 func (ac *ResourceServerController) RegisterRoutes(engine *gin.Engine) {
 	resourceServerGroup := engine.Group("/resource-servers")
 	{
-		resourceServerGroup.POST("/", ac.CreateHandler)
-		resourceServerGroup.GET("/", ac.GetAllHandler)
-		resourceServerGroup.GET("/:id", ac.GetByIDHandler)
-		resourceServerGroup.PUT("/:id", ac.UpdateHandler)
+		resourceServerGroup.POST("/", requireScopes(core.ManageResourceServersScope), ac.CreateHandler)
+		resourceServerGroup.GET("/", requireScopes(core.ViewResourceServersScope), ac.GetAllHandler)
+		resourceServerGroup.GET("/:id", requireScopes(core.ViewResourceServersScope), ac.GetByIDHandler)
+		resourceServerGroup.PUT("/:id", requireScopes(core.ManageResourceServersScope), ac.UpdateHandler)
 	}
 }
 
 // @Summary Create a new resource server
 // @Param body body resource_server_dtos.CreateResourceServerDTO true "Resource server creation data"
-// @Description Create a new resource server with the provided display name and description
+// @Description Create a new resource server with the provided display name, description and audience identifier
 // @Accept json
 // @Produce json
 // @Success 201 {object} entities.ResourceServer
 // @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
+// @Failure 409 {object} interface{}
 // @Failure 500 {object} interface{}
 // @Router /resource-servers/ [post]
 // @Tags ResourceServers
+// @Security ApiKeyAuth
 func (ac *ResourceServerController) CreateHandler(c *gin.Context) {
 	var dto resource_server_dtos.CreateResourceServerDTO
 	if err := c.ShouldBindJSON(&dto); err != nil {
@@ -43,10 +47,25 @@ func (ac *ResourceServerController) CreateHandler(c *gin.Context) {
 	entity := (&entities.ResourceServer{}).CreateNew()
 	entity.DisplayName = dto.DisplayName
 	entity.Description = dto.Description
+	entity.Scopes = scopeDefinitions(dto.Scopes)
 
 	// Generate a unique name (slug) from the display name
 	// Replace spaces with hyphens and convert to lowercase
 	entity.Name = strings.ToLower(strings.ReplaceAll(dto.DisplayName, " ", "-"))
+
+	// The identifier is the audience of the issued tokens and cannot change
+	entity.Identifier = dto.Identifier
+	if entity.Identifier == "" {
+		entity.Identifier = core.ResourceIdentifierPrefix + entity.Name
+	}
+	if !core.IsValidResourceIndicator(entity.Identifier) {
+		c.JSON(400, gin.H{"error": "Identifier must be an absolute URI without a fragment"})
+		return
+	}
+	if entity.LoadByIdentifier(entity.Identifier) != nil {
+		c.JSON(409, gin.H{"error": "Identifier is already used by another resource server"})
+		return
+	}
 	err := entity.Save()
 	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to create resource server"})
@@ -62,9 +81,12 @@ func (ac *ResourceServerController) CreateHandler(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {array} entities.ResourceServer
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
 // @Failure 500 {object} interface{}
 // @Router /resource-servers/ [get]
 // @Tags ResourceServers
+// @Security ApiKeyAuth
 func (ac *ResourceServerController) GetAllHandler(c *gin.Context) {
 	limit := c.DefaultQuery("limit", "10")
 	page := c.DefaultQuery("page", "1")
@@ -89,9 +111,12 @@ func (ac *ResourceServerController) GetAllHandler(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} entities.ResourceServer
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
 // @Failure 404 {object} interface{}
 // @Router /resource-servers/{id} [get]
 // @Tags ResourceServers
+// @Security ApiKeyAuth
 func (ac *ResourceServerController) GetByIDHandler(c *gin.Context) {
 	id := c.Param("id")
 	resourceServer := (&entities.ResourceServer{}).LoadByID(id)
@@ -105,15 +130,18 @@ func (ac *ResourceServerController) GetByIDHandler(c *gin.Context) {
 // @Summary Update an existing resource server
 // @Param id path string true "Resource Server ID"
 // @Param body body resource_server_dtos.UpdateResourceServerDTO true "Resource Server update data"
-// @Description Update an existing resource server's display name and description
+// @Description Update an existing resource server's display name, description and scope definitions
 // @Accept json
 // @Produce json
 // @Success 200 {object} entities.ResourceServer
 // @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
 // @Failure 404 {object} interface{}
 // @Failure 500 {object} interface{}
 // @Router /resource-servers/{id} [put]
 // @Tags ResourceServers
+// @Security ApiKeyAuth
 func (ac *ResourceServerController) UpdateHandler(c *gin.Context) {
 	dto := resource_server_dtos.UpdateResourceServerDTO{}
 	if err := c.ShouldBindJSON(&dto); err != nil {
@@ -128,6 +156,9 @@ func (ac *ResourceServerController) UpdateHandler(c *gin.Context) {
 	}
 	resourceServer.DisplayName = dto.DisplayName
 	resourceServer.Description = dto.Description
+	if dto.Scopes != nil {
+		resourceServer.Scopes = scopeDefinitions(dto.Scopes)
+	}
 	err := resourceServer.Save()
 	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to update resource server"})
@@ -135,3 +166,15 @@ func (ac *ResourceServerController) UpdateHandler(c *gin.Context) {
 	}
 	c.JSON(200, resourceServer)
 }
+
+// Converts the scope definitions of a request
+func scopeDefinitions(dtos []resource_server_dtos.ScopeDTO) []entities.ResourceServerScope {
+	scopes := []entities.ResourceServerScope{}
+	for _, dto := range dtos {
+		scopes = append(scopes, entities.ResourceServerScope{
+			Value:       dto.Value,
+			Description: dto.Description,
+		})
+	}
+	return scopes
+}
@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keyloom/web-api/core"
+	session_dtos "github.com/keyloom/web-api/dtos/session"
+	token_dtos "github.com/keyloom/web-api/dtos/token"
+	"github.com/keyloom/web-api/entities"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// How often failed back-channel logout deliveries are checked for retries
+var logoutDeliveryCheckInterval = 30 * time.Second
+
+type SessionController struct{}
+
+var _ core.Controller = (*SessionController)(nil)
+
+func (sc *SessionController) RegisterRoutes(engine *gin.Engine) {
+	logoutGroup := engine.Group("/logout")
+	{
+		logoutGroup.GET("", sc.LogoutHandler)
+		logoutGroup.POST("", sc.LogoutHandler)
+	}
+}
+
+// @Summary End session endpoint
+// @Param id_token_hint query string false "ID token previously issued to the client, expired ones are accepted"
+// @Param client_id query string false "Client ID, required with post_logout_redirect_uri when no id_token_hint is sent"
+// @Param post_logout_redirect_uri query string false "Registered post logout redirect URI of the client"
+// @Param state query string false "Opaque value returned to the client"
+// @Description Sign the user out of their browser session (OpenID Connect RP-Initiated Logout). The user is asked to confirm unless a valid id_token_hint of the signed in user is sent. The clients of the session are notified through their back-channel logout URI and, from the signed out page, their front-channel logout URI. The user is then redirected to the post logout redirect URI when one is given.
+// @Produce html
+// @Success 200 {string} string "Confirmation or signed out page, which loads the front-channel logout URIs before redirecting"
+// @Success 302 {string} string "Redirect to the post logout redirect URI"
+// @Failure 400 {string} string "Error page"
+// @Router /logout [get]
+// @Router /logout [post]
+// @Tags OpenID Connect
+func (sc *SessionController) LogoutHandler(c *gin.Context) {
+	var dto session_dtos.LogoutDTO
+	if err := c.ShouldBind(&dto); err != nil {
+		renderAuthorizeError(c, "invalid_request", err.Error())
+		return
+	}
+
+	// the client is identified by the ID token it received, or its client_id
+	var hint *token_dtos.JWTPayload
+	clientID := dto.ClientID
+	if dto.IDTokenHint != "" {
+		var err error
+		hint, err = newTokenService().ParseIDTokenHint(dto.IDTokenHint)
+		if err != nil {
+			renderAuthorizeError(c, "invalid_request", err.Error())
+			return
+		}
+		if clientID != "" && clientID != hint.ClientID {
+			renderAuthorizeError(c, "invalid_request", "client_id does not match the id_token_hint")
+			return
+		}
+		clientID = hint.ClientID
+	}
+	var application *entities.Application
+	if clientID != "" {
+		application = (&entities.Application{}).LoadByClientID(clientID)
+		if application == nil {
+			renderAuthorizeError(c, "invalid_client", "unknown client_id")
+			return
+		}
+	}
+	// users are only sent back to URIs the client registered
+	if dto.PostLogoutRedirectURI != "" {
+		if application == nil {
+			renderAuthorizeError(c, "invalid_request", "client_id or id_token_hint is required with post_logout_redirect_uri")
+			return
+		}
+		if !slices.Contains(application.PostLogoutRedirectURIs, dto.PostLogoutRedirectURI) {
+			renderAuthorizeError(c, "invalid_request", "post_logout_redirect_uri is not registered for this client")
+			return
+		}
+	}
+
+	// anyone can send the user here, so the user confirms unless the client
+	// proves the request is about them. The confirmation page posts back,
+	// which the SameSite cookie limits to this site.
+	session := currentSession(c)
+	if session != nil && c.Request.Method == http.MethodGet && (hint == nil || hint.Sub != session.UserID.Hex()) {
+		data := gin.H{
+			"ClientID":              clientID,
+			"PostLogoutRedirectURI": dto.PostLogoutRedirectURI,
+			"State":                 dto.State,
+		}
+		if application != nil {
+			data["ApplicationName"] = application.Name
+		}
+		c.HTML(http.StatusOK, "logout.html", data)
+		return
+	}
+
+	var frontchannelLogoutURIs []string
+	if session != nil {
+		frontchannelLogoutURIs = frontchannelLogoutURIsOf(session)
+		endSession(session)
+	}
+	clearSessionCookie(c)
+
+	redirectURI := ""
+	if dto.PostLogoutRedirectURI != "" {
+		target, err := url.Parse(dto.PostLogoutRedirectURI)
+		if err != nil {
+			renderAuthorizeError(c, "invalid_request", "invalid post_logout_redirect_uri")
+			return
+		}
+		if dto.State != "" {
+			query := target.Query()
+			query.Set("state", dto.State)
+			target.RawQuery = query.Encode()
+		}
+		redirectURI = target.String()
+	}
+	// the clients are signed out from the browser before it is sent back
+	if redirectURI != "" && len(frontchannelLogoutURIs) == 0 {
+		c.Redirect(http.StatusFound, redirectURI)
+		return
+	}
+	c.HTML(http.StatusOK, "logged-out.html", gin.H{
+		"FrontchannelLogoutURIs": frontchannelLogoutURIs,
+		"RedirectURI":            redirectURI,
+	})
+}
+
+// Delivers due back-channel logout notifications, then keeps retrying failed
+// ones in the background
+func (sc *SessionController) StartLogoutDelivery() {
+	go func() {
+		sc.DeliverLogouts()
+		ticker := time.NewTicker(logoutDeliveryCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			sc.DeliverLogouts()
+		}
+	}()
+}
+
+// Sends a logout token to the back-channel logout URI of each client with a
+// due delivery and records the outcome
+func (sc *SessionController) DeliverLogouts() {
+	for delivery := (&entities.LogoutDelivery{}).ClaimNext(); delivery != nil; delivery = (&entities.LogoutDelivery{}).ClaimNext() {
+		err := sc.deliverLogout(delivery)
+		if err != nil {
+			fmt.Printf("[LOGOUT] Back-channel logout of %s failed: %v\n", delivery.ClientID, err)
+		}
+		delivery.RecordAttempt(err)
+	}
+}
+
+func (sc *SessionController) deliverLogout(delivery *entities.LogoutDelivery) error {
+	// the client may have changed its logout URI since the session ended
+	application := (&entities.Application{}).LoadByClientID(delivery.ClientID)
+	if application == nil || application.BackchannelLogoutURI != delivery.URI {
+		return errors.New("the back-channel logout URI is no longer registered")
+	}
+	logoutToken, err := newTokenService().GenerateLogoutToken(token_dtos.LogoutTokenClaims{
+		Subject:   delivery.UserID.Hex(),
+		ClientID:  delivery.ClientID,
+		SessionID: delivery.SessionID,
+	})
+	if err != nil {
+		return err
+	}
+	return core.DeliverLogoutToken(delivery.URI, logoutToken)
+}
+
+// Ends a browser session. The clients the user signed in to through it are
+// notified in the background when they registered a back-channel logout URI.
+func endSession(session *entities.Session) {
+	session.Delete()
+	scheduled := false
+	for _, clientID := range session.ClientIDs {
+		application := (&entities.Application{}).LoadByClientID(clientID)
+		if application == nil || application.BackchannelLogoutURI == "" {
+			continue
+		}
+		delivery := (&entities.LogoutDelivery{}).CreateNew()
+		delivery.ClientID = clientID
+		delivery.URI = application.BackchannelLogoutURI
+		delivery.UserID = session.UserID
+		delivery.SessionID = session.SID
+		if delivery.Save() == nil {
+			scheduled = true
+		}
+	}
+	if scheduled {
+		go (&SessionController{}).DeliverLogouts()
+	}
+}
+
+// Returns the front-channel logout URIs of the clients the user signed in to
+// through the session, to be loaded by the browser
+func frontchannelLogoutURIsOf(session *entities.Session) []string {
+	config, err := (&core.EnvManager{}).GetTokenConfig()
+	if err != nil {
+		return nil
+	}
+	logoutURIs := []string{}
+	for _, clientID := range session.ClientIDs {
+		application := (&entities.Application{}).LoadByClientID(clientID)
+		if application == nil || application.FrontchannelLogoutURI == "" {
+			continue
+		}
+		target, err := url.Parse(application.FrontchannelLogoutURI)
+		if err != nil {
+			continue
+		}
+		if application.FrontchannelLogoutSessionRequired {
+			query := target.Query()
+			query.Set("iss", config.Issuer)
+			query.Set("sid", session.SID)
+			target.RawQuery = query.Encode()
+		}
+		logoutURIs = append(logoutURIs, target.String())
+	}
+	return logoutURIs
+}
+
+// Returns the browser session of the request, or nil if the user is not
+// signed in
+func currentSession(c *gin.Context) *entities.Session {
+	token, err := c.Cookie(core.SessionCookieName)
+	if err != nil || token == "" {
+		return nil
+	}
+	return (&entities.Session{}).LoadByToken(token)
+}
+
+// Starts the browser session of a user who just authenticated. The current
+// session is renewed when it belongs to the same user, so the clients signed
+// in during it are kept, and ended otherwise.
+func startSession(c *gin.Context, userID primitive.ObjectID) (*entities.Session, error) {
+	session := currentSession(c)
+	if session != nil && session.UserID != userID {
+		endSession(session)
+		session = nil
+	}
+	if session == nil {
+		session = (&entities.Session{}).CreateNew()
+	}
+	token, err := session.Start(userID)
+	if err != nil {
+		return nil, err
+	}
+	setSessionCookie(c, token, int(core.SessionLifetime.Seconds()))
+	return session, nil
+}
+
+func clearSessionCookie(c *gin.Context) {
+	setSessionCookie(c, "", -1)
+}
+
+// Sets the session cookie. It is kept from scripts and, when the issuer is
+// served over HTTPS, from plain HTTP requests. SameSite Lax still sends it
+// when clients redirect the user to /authorize.
+func setSessionCookie(c *gin.Context, token string, maxAge int) {
+	secure := false
+	if config, err := (&core.EnvManager{}).GetTokenConfig(); err == nil {
+		secure = strings.HasPrefix(config.Issuer, "https://")
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(core.SessionCookieName, token, maxAge, "/", "", secure, true)
+}
@@ -1,7 +1,11 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/keyloom/web-api/core"
@@ -13,19 +17,46 @@ type TokenController struct{}
 
 var _ core.Controller = (*TokenController)(nil)
 
+// Returns a token service backed by the persisted signing keys and denylist
+func newTokenService() *core.TokenService {
+	return &core.TokenService{
+		Keys:     &entities.SigningKey{},
+		Denylist: &entities.RevokedToken{},
+	}
+}
+
 func (tc *TokenController) RegisterRoutes(engine *gin.Engine) {
 	tokenGroup := engine.Group("/token")
 	{
 		tokenGroup.POST("/", tc.TokenDispatchHandler)
 		tokenGroup.GET("/me/validate", tc.ValidateToken)
+		tokenGroup.POST("/introspect", tc.IntrospectHandler)
+		tokenGroup.POST("/revoke", tc.RevokeHandler)
 	}
 }
 
 // @Summary Token dispatch endpoint
 // @Param grant_type formData string true "Grant type"
+// @Param DPoP header string false "DPoP proof binding the issued tokens to the client key, with a nonce from the DPoP-Nonce header"
 // @Param username formData string false "Username for password grant"
 // @Param password formData string false "Password for password grant"
 // @Param client_id formData string false "Client ID for client credentials grant"
+// @Param client_secret formData string false "Client secret for client credentials grant"
+// @Param client_assertion_type formData string false "urn:ietf:params:oauth:client-assertion-type:jwt-bearer for private_key_jwt and client_secret_jwt clients"
+// @Param client_assertion formData string false "JWT authenticating the client"
+// @Param code formData string false "Authorization code for authorization code grant"
+// @Param redirect_uri formData string false "Redirect URI used in the authorization request"
+// @Param code_verifier formData string false "PKCE code verifier for authorization code grant"
+// @Param refresh_token formData string false "Refresh token for refresh token grant"
+// @Param scope formData string false "Space delimited scopes, for refresh token and token exchange grants a subset of the original scopes"
+// @Param device_code formData string false "Device code for device code grant"
+// @Param resource formData []string false "Resource indicators of the resource servers the token is meant for" collectionFormat(multi)
+// @Param subject_token formData string false "Keyloom access token issued for a resource server linked to the client, for token exchange grant"
+// @Param subject_token_type formData string false "Type of the subject token for token exchange grant"
+// @Param actor_token formData string false "Keyloom access token of the acting party for token exchange grant"
+// @Param actor_token_type formData string false "Type of the actor token for token exchange grant"
+// @Param audience formData string false "Resource server name or identifier the exchanged token is meant for"
+// @Param requested_token_type formData string false "Must be an access token type when set"
 // @Description Dispatch tokens based on the provided grant type
 // @Accept application/x-www-form-urlencoded
 // @Produce json
@@ -38,27 +69,67 @@ func (tc *TokenController) TokenDispatchHandler(c *gin.Context) {
 	grantType := c.PostForm("grant_type")
 	if grantType == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "grant_type is required"})
+		return
+	}
+
+	if !slices.Contains(core.GrantTypes, grantType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported_grant_type"})
+		return
+	}
+
+	// the client must authenticate as registered and be allowed the grant type
+	application, err := identifyClient(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client", "error_description": err.Error()})
+		return
+	}
+	if !application.AllowsGrantType(grantType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unauthorized_client", "error_description": "the client may not use this grant type"})
+		return
+	}
+	// certificate-bound tokens can only be issued over mutual TLS
+	if application.TLSClientCertificateBoundAccessTokens && len(clientCertificates(c)) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "a client certificate is required"})
+		return
+	}
+	if !verifyTokenRequestDPoP(c, application) {
+		return
 	}
 
 	switch grantType {
 	case core.ClientCredentialsGrant:
 		{
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "not implemented"})
+			tc.ClientCredentialsGrantHandler(c, application)
 		}
 
 	case core.PasswordGrant:
 		{
-			tc.PasswordGrantHandler(c)
+			tc.PasswordGrantHandler(c, application)
 		}
 
-	default:
+	case core.CodeGrant:
+		{
+			tc.AuthorizationCodeGrantHandler(c, application)
+		}
+
+	case core.RefreshTokenGrant:
+		{
+			tc.RefreshTokenGrantHandler(c, application)
+		}
+
+	case core.DeviceCodeGrant:
 		{
-			c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported grant_type"})
+			tc.DeviceCodeGrantHandler(c, application)
+		}
+
+	case core.TokenExchangeGrant:
+		{
+			tc.TokenExchangeGrantHandler(c, application)
 		}
 	}
 }
 
-func (tc *TokenController) PasswordGrantHandler(c *gin.Context) {
+func (tc *TokenController) PasswordGrantHandler(c *gin.Context, application *entities.Application) {
 	var req token_dtos.PasswordGrantRequest
 	if err := c.ShouldBind(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -78,18 +149,499 @@ func (tc *TokenController) PasswordGrantHandler(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
+	if user.IsDisabled() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "user is disabled"})
+		return
+	}
+
+	// narrow the requested scopes down to what the user granted the application
+	grantScopes := (&entities.Grant{}).AllowedScopes(user.ID, application.ID)
+	scopes := core.EffectiveScopes(core.ParseScopes(req.Scope), application.Scopes, grantScopes)
+	if len(scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope", "error_description": "none of the requested scopes are allowed"})
+		return
+	}
+
+	audiences, err := resolveAudiences(application, req.Resources, nil)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_target", "error_description": err.Error()})
+		return
+	}
 
 	// generate token
-	token, err := (&core.TokenService{}).GenerateToken(user.ID.Hex())
+	token, err := newTokenService().GenerateToken(token_dtos.TokenClaims{
+		Subject:      user.ID.Hex(),
+		ClientID:     application.ClientID,
+		Audience:     audiences,
+		Scopes:       scopes,
+		Confirmation: tokenConfirmation(c, application),
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
 	}
 
+	// start a new refresh token family
+	refreshToken := (&entities.RefreshToken{}).CreateNew()
+	refreshToken.UserID = user.ID
+	refreshToken.ApplicationID = application.ID
+	refreshToken.Scopes = scopes
+	if len(req.Resources) > 0 {
+		refreshToken.Resources = audiences
+	}
+	refreshToken.AuthTime = time.Now().Unix()
+	refreshToken.DPoPJkt = refreshTokenDPoPKey(c, application)
+	token.RefreshToken, err = refreshToken.Issue()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
+		return
+	}
+
 	// respond with token
 	c.JSON(http.StatusOK, token)
 }
 
+func (tc *TokenController) ClientCredentialsGrantHandler(c *gin.Context, application *entities.Application) {
+	// tokens on behalf of the client itself require it to authenticate
+	if application.IsPublic() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unauthorized_client", "error_description": "public clients cannot use the client credentials grant"})
+		return
+	}
+
+	// there is no user, the application scopes alone bound the request
+	scopes := application.Scopes
+	if scope := c.PostForm("scope"); scope != "" {
+		scopes = core.IntersectScopes(core.ParseScopes(scope), application.Scopes)
+	}
+	if len(scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope", "error_description": "none of the requested scopes are allowed"})
+		return
+	}
+
+	audiences, err := resolveAudiences(application, c.PostFormArray("resource"), nil)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_target", "error_description": err.Error()})
+		return
+	}
+
+	// generate token on behalf of the client itself
+	token, err := newTokenService().GenerateToken(token_dtos.TokenClaims{
+		Subject:      application.ClientID,
+		ClientID:     application.ClientID,
+		Audience:     audiences,
+		Scopes:       scopes,
+		Confirmation: tokenConfirmation(c, application),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	// respond with token
+	c.JSON(http.StatusOK, token)
+}
+
+func (tc *TokenController) AuthorizationCodeGrantHandler(c *gin.Context, application *entities.Application) {
+	var req token_dtos.AuthorizationCodeGrantRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	// consume the code, it can never be used twice
+	authorizationCode := (&entities.AuthorizationCode{}).Consume(req.Code)
+	if authorizationCode == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "invalid or expired authorization code"})
+		return
+	}
+	if authorizationCode.ClientID != application.ClientID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "authorization code was issued to another client"})
+		return
+	}
+	if (authorizationCode.RedirectURIProvided || req.RedirectURI != "") && req.RedirectURI != authorizationCode.RedirectURI {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "redirect_uri does not match the authorization request"})
+		return
+	}
+
+	// verify PKCE
+	if !core.VerifyCodeChallenge(req.CodeVerifier, authorizationCode.CodeChallenge, authorizationCode.CodeChallengeMethod) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "code_verifier does not match the code challenge"})
+		return
+	}
+
+	// the token may target a subset of the resources of the authorization request
+	audiences, err := resolveAudiences(application, req.Resources, authorizationCode.Resources)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_target", "error_description": err.Error()})
+		return
+	}
+
+	// generate token
+	token, err := newTokenService().GenerateToken(token_dtos.TokenClaims{
+		Subject:      authorizationCode.UserID.Hex(),
+		ClientID:     application.ClientID,
+		Audience:     audiences,
+		Scopes:       authorizationCode.Scopes,
+		Confirmation: tokenConfirmation(c, application),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	// OpenID Connect authentication
+	if slices.Contains(authorizationCode.Scopes, core.OpenIDScope) {
+		token.IDToken, err = newTokenService().GenerateIDToken(token_dtos.IDTokenClaims{
+			Subject:     authorizationCode.UserID.Hex(),
+			ClientID:    application.ClientID,
+			Nonce:       authorizationCode.Nonce,
+			AuthTime:    authorizationCode.AuthTime,
+			AccessToken: token.AccessToken,
+			SessionID:   authorizationCode.SessionID,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate id token"})
+			return
+		}
+	}
+
+	// start a new refresh token family
+	refreshToken := (&entities.RefreshToken{}).CreateNew()
+	refreshToken.UserID = authorizationCode.UserID
+	refreshToken.ApplicationID = application.ID
+	refreshToken.Scopes = authorizationCode.Scopes
+	refreshToken.Resources = authorizationCode.Resources
+	refreshToken.AuthTime = authorizationCode.AuthTime
+	refreshToken.SessionID = authorizationCode.SessionID
+	refreshToken.DPoPJkt = refreshTokenDPoPKey(c, application)
+	token.RefreshToken, err = refreshToken.Issue()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
+		return
+	}
+
+	// respond with token
+	c.JSON(http.StatusOK, token)
+}
+
+func (tc *TokenController) RefreshTokenGrantHandler(c *gin.Context, application *entities.Application) {
+	var req token_dtos.RefreshTokenGrantRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	// load the presented refresh token
+	presented := (&entities.RefreshToken{}).LoadByToken(req.RefreshToken)
+	if presented == nil || presented.ApplicationID != application.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "invalid refresh token"})
+		return
+	}
+	// bound refresh tokens need a proof of possession of the same key
+	if presented.DPoPJkt != "" && presented.DPoPJkt != dpopKey(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "refresh token is bound to another DPoP key"})
+		return
+	}
+
+	// a consumed token being presented again means it has leaked,
+	// so every token of its family is revoked
+	refreshToken, err := presented.Rotate()
+	if errors.Is(err, entities.ErrRefreshTokenInactive) || errors.Is(err, entities.ErrRefreshTokenReused) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rotate refresh token"})
+		return
+	}
+
+	// the requested scopes may only narrow down the original grant
+	scopes := presented.Scopes
+	if req.Scope != "" {
+		requested := core.ParseScopes(req.Scope)
+		scopes = core.IntersectScopes(requested, presented.Scopes)
+		if len(scopes) != len(requested) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope", "error_description": "requested scope exceeds the original grant"})
+			return
+		}
+	}
+	// the user may have narrowed down the grant in the meantime
+	grantScopes := (&entities.Grant{}).AllowedScopes(presented.UserID, application.ID)
+	scopes = core.IntersectScopes(scopes, append(core.IntersectScopes(application.Scopes, grantScopes), core.OpenIDScopes...))
+	if len(scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope", "error_description": "none of the granted scopes are allowed anymore"})
+		return
+	}
+
+	// the token may target any of the resources of the original grant
+	audiences, err := resolveAudiences(application, req.Resources, presented.Resources)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_target", "error_description": err.Error()})
+		return
+	}
+
+	// generate token
+	token, err := newTokenService().GenerateToken(token_dtos.TokenClaims{
+		Subject:      presented.UserID.Hex(),
+		ClientID:     application.ClientID,
+		Audience:     audiences,
+		Scopes:       scopes,
+		Confirmation: tokenConfirmation(c, application),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	// refreshed OpenID Connect sessions get a new ID token
+	if slices.Contains(scopes, core.OpenIDScope) {
+		token.IDToken, err = newTokenService().GenerateIDToken(token_dtos.IDTokenClaims{
+			Subject:     presented.UserID.Hex(),
+			ClientID:    application.ClientID,
+			AuthTime:    presented.AuthTime,
+			AccessToken: token.AccessToken,
+			SessionID:   presented.SessionID,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate id token"})
+			return
+		}
+	}
+
+	// issue the successor of the presented refresh token
+	token.RefreshToken, err = refreshToken.Issue()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
+		return
+	}
+
+	// respond with token
+	c.JSON(http.StatusOK, token)
+}
+
+func (tc *TokenController) DeviceCodeGrantHandler(c *gin.Context, application *entities.Application) {
+	var req token_dtos.DeviceCodeGrantRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	deviceCode := (&entities.DeviceCode{}).LoadByDeviceCode(req.DeviceCode)
+	if deviceCode == nil || deviceCode.ClientID != application.ClientID || deviceCode.Consumed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "invalid device code"})
+		return
+	}
+	if time.Now().After(deviceCode.ExpireAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expired_token", "error_description": "the device code has expired"})
+		return
+	}
+
+	// the device must respect the polling interval
+	tooFast, err := deviceCode.RecordPoll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "failed to record device poll"})
+		return
+	}
+	if tooFast {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "slow_down", "error_description": "polling too frequently", "interval": deviceCode.Interval})
+		return
+	}
+
+	switch deviceCode.Status {
+	case core.DeviceCodeStatusPending:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "authorization_pending", "error_description": "the user has not approved the device yet"})
+		return
+	case core.DeviceCodeStatusDenied:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "access_denied", "error_description": "the user denied the device"})
+		return
+	}
+
+	audiences, err := resolveAudiences(application, req.Resources, nil)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_target", "error_description": err.Error()})
+		return
+	}
+
+	// tokens are issued only once per approval
+	if !deviceCode.MarkConsumed() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "invalid device code"})
+		return
+	}
+
+	// generate token
+	token, err := newTokenService().GenerateToken(token_dtos.TokenClaims{
+		Subject:      deviceCode.UserID.Hex(),
+		ClientID:     application.ClientID,
+		Audience:     audiences,
+		Scopes:       deviceCode.Scopes,
+		Confirmation: tokenConfirmation(c, application),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	// OpenID Connect authentication
+	if slices.Contains(deviceCode.Scopes, core.OpenIDScope) {
+		token.IDToken, err = newTokenService().GenerateIDToken(token_dtos.IDTokenClaims{
+			Subject:     deviceCode.UserID.Hex(),
+			ClientID:    application.ClientID,
+			AuthTime:    deviceCode.AuthTime,
+			AccessToken: token.AccessToken,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate id token"})
+			return
+		}
+	}
+
+	// start a new refresh token family
+	refreshToken := (&entities.RefreshToken{}).CreateNew()
+	refreshToken.UserID = deviceCode.UserID
+	refreshToken.ApplicationID = application.ID
+	refreshToken.Scopes = deviceCode.Scopes
+	if len(req.Resources) > 0 {
+		refreshToken.Resources = audiences
+	}
+	refreshToken.AuthTime = deviceCode.AuthTime
+	refreshToken.DPoPJkt = refreshTokenDPoPKey(c, application)
+	token.RefreshToken, err = refreshToken.Issue()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate refresh token"})
+		return
+	}
+
+	// respond with token
+	c.JSON(http.StatusOK, token)
+}
+
+func (tc *TokenController) TokenExchangeGrantHandler(c *gin.Context, application *entities.Application) {
+	var req token_dtos.TokenExchangeGrantRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+	if req.RequestedTokenType != "" && req.RequestedTokenType != core.AccessTokenType {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "only access tokens can be requested"})
+		return
+	}
+
+	// only confidential clients may exchange tokens
+	if application.IsPublic() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unauthorized_client", "error_description": "public clients cannot exchange tokens"})
+		return
+	}
+
+	// the subject token must be an access token issued by us. ValidateToken
+	// only accepts at+jwt typed tokens, so the ID and logout tokens a client
+	// received can never be exchanged.
+	if !isExchangeableTokenType(req.SubjectTokenType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "unsupported subject_token_type"})
+		return
+	}
+	subject, err := newTokenService().ValidateToken(req.SubjectToken)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "invalid subject token"})
+		return
+	}
+	// a client may only exchange tokens that were sent to one of its APIs
+	if !slices.ContainsFunc(subject.Aud, func(audience string) bool {
+		return slices.Contains(application.Audiences(), audience)
+	}) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "the subject token is not meant for a resource server linked to the client"})
+		return
+	}
+
+	// the actor defaults to the calling client
+	actor := map[string]interface{}{"sub": application.ClientID, "client_id": application.ClientID}
+	if req.ActorToken != "" {
+		if !isExchangeableTokenType(req.ActorTokenType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "unsupported actor_token_type"})
+			return
+		}
+		actorPayload, err := newTokenService().ValidateToken(req.ActorToken)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "invalid actor token"})
+			return
+		}
+		actor = map[string]interface{}{"sub": actorPayload.Sub}
+		if actorPayload.ClientID != "" {
+			actor["client_id"] = actorPayload.ClientID
+		}
+	}
+	// previous actors are nested so the whole delegation chain is kept
+	if subject.Act != nil {
+		actor["act"] = subject.Act
+	}
+
+	// the new token targets a single resource server linked to the client,
+	// named by its resource indicator or by its logical name
+	var resourceServer *entities.ResourceServer
+	switch {
+	case req.Resource != "":
+		resourceServer = application.LinkedResourceServer(req.Resource)
+		if resourceServer != nil && resourceServer.Identifier != req.Resource {
+			resourceServer = nil
+		}
+	case req.Audience != "":
+		resourceServer = application.LinkedResourceServer(req.Audience)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_target", "error_description": "a resource or audience is required"})
+		return
+	}
+	if resourceServer == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_target", "error_description": "the client may not request tokens for this resource"})
+		return
+	}
+
+	// the requested scopes may only narrow down the subject token
+	original := core.ParseScopes(subject.Scope)
+	requested := original
+	if req.Scope != "" {
+		requested = core.ParseScopes(req.Scope)
+		if len(core.IntersectScopes(requested, original)) != len(requested) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope", "error_description": "requested scope exceeds the subject token"})
+			return
+		}
+	}
+	// and like every other grant they are bounded by what the client may
+	// request and, for users, by what the user granted it. Subjects that are
+	// not users are clients, which only the application scopes bound.
+	grantScopes := application.Scopes
+	if user := (&entities.User{}).LoadByID(subject.Sub); user != nil {
+		grantScopes = (&entities.Grant{}).AllowedScopes(user.ID, application.ID)
+	}
+	scopes := core.IntersectScopes(core.EffectiveScopes(requested, application.Scopes, grantScopes), original)
+	if len(scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope", "error_description": "none of the requested scopes are allowed"})
+		return
+	}
+
+	// generate token, it never outlives the subject token
+	token, err := newTokenService().GenerateToken(token_dtos.TokenClaims{
+		Subject:      subject.Sub,
+		ClientID:     application.ClientID,
+		Audience:     []string{resourceServer.Identifier},
+		Scopes:       scopes,
+		Act:          actor,
+		NotAfter:     subject.Exp,
+		Confirmation: tokenConfirmation(c, application),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+	token.IssuedTokenType = core.AccessTokenType
+
+	// respond with token
+	c.JSON(http.StatusOK, token)
+}
+
+// Reports whether tokens of the given type can be exchanged
+func isExchangeableTokenType(tokenType string) bool {
+	return tokenType == core.AccessTokenType || tokenType == core.JWTTokenType
+}
+
 // @Summary Validate token endpoint
 // @Description Validate the provided JWT token
 // @Produce json
@@ -100,15 +652,13 @@ func (tc *TokenController) PasswordGrantHandler(c *gin.Context) {
 // @Tags Tokens
 // @Security ApiKeyAuth
 func (tc *TokenController) ValidateToken(c *gin.Context) {
-	tokenString := c.GetHeader("Authorization")
-	if tokenString == "" {
+	tokenString, ok := bearerToken(c)
+	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Authorization header is required"})
 		return
 	}
 
-	tokenString = tokenString[len("Bearer "):]
-
-	token, err := (&core.TokenService{}).ValidateToken(tokenString)
+	token, err := newTokenService().ValidateToken(tokenString)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 		return
@@ -116,3 +666,193 @@ func (tc *TokenController) ValidateToken(c *gin.Context) {
 
 	c.JSON(http.StatusOK, token)
 }
+
+// @Summary Token introspection endpoint
+// @Param token formData string true "Access or refresh token to introspect"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Param client_id formData string false "Client ID when not using HTTP Basic authentication"
+// @Param client_secret formData string false "Client secret when not using HTTP Basic authentication"
+// @Param client_assertion_type formData string false "urn:ietf:params:oauth:client-assertion-type:jwt-bearer for private_key_jwt and client_secret_jwt clients"
+// @Param client_assertion formData string false "JWT authenticating the client"
+// @Description Report the state of a token to an authenticated client (RFC 7662)
+// @Accept application/x-www-form-urlencoded
+// @Produce json
+// @Success 200 {object} token_dtos.IntrospectionResponse
+// @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Router /token/introspect [post]
+// @Tags Tokens
+func (tc *TokenController) IntrospectHandler(c *gin.Context) {
+	// only authenticated clients may introspect tokens
+	application, err := authenticateClient(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client", "error_description": err.Error()})
+		return
+	}
+
+	var req token_dtos.IntrospectionRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	// the hint only decides which lookup is attempted first
+	lookups := []func(*entities.Application, string) *token_dtos.IntrospectionResponse{
+		tc.introspectAccessToken,
+		tc.introspectRefreshToken,
+	}
+	if req.TokenTypeHint == core.RefreshTokenTypeHint {
+		slices.Reverse(lookups)
+	}
+	for _, lookup := range lookups {
+		if response := lookup(application, req.Token); response != nil {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, token_dtos.IntrospectionResponse{Active: false})
+}
+
+func (tc *TokenController) introspectAccessToken(_ *entities.Application, token string) *token_dtos.IntrospectionResponse {
+	payload, err := newTokenService().ValidateToken(token)
+	if err != nil {
+		return nil
+	}
+	tokenType := "Bearer"
+	if payload.Cnf != nil && payload.Cnf.Jkt != "" {
+		tokenType = core.DPoPTokenType
+	}
+	return &token_dtos.IntrospectionResponse{
+		Active:    true,
+		Scope:     payload.Scope,
+		ClientID:  payload.ClientID,
+		Sub:       payload.Sub,
+		Exp:       payload.Exp,
+		Iat:       payload.Iat,
+		Aud:       payload.Aud,
+		Iss:       payload.Iss,
+		Jti:       payload.Jti,
+		TokenType: tokenType,
+		Cnf:       payload.Cnf,
+	}
+}
+
+// Refresh tokens are only disclosed to the client they were issued to
+func (tc *TokenController) introspectRefreshToken(application *entities.Application, token string) *token_dtos.IntrospectionResponse {
+	refreshToken := (&entities.RefreshToken{}).LoadByToken(token)
+	if refreshToken == nil || refreshToken.ApplicationID != application.ID {
+		return nil
+	}
+	// consumed and revoked tokens are known but no longer active
+	if !refreshToken.IsActive() {
+		return &token_dtos.IntrospectionResponse{Active: false}
+	}
+	return &token_dtos.IntrospectionResponse{
+		Active:    true,
+		Scope:     strings.Join(refreshToken.Scopes, " "),
+		ClientID:  application.ClientID,
+		Sub:       refreshToken.UserID.Hex(),
+		Exp:       refreshToken.ExpireAt.Unix(),
+		Iat:       refreshToken.CreatedAt,
+		TokenType: core.RefreshTokenTypeHint,
+	}
+}
+
+// @Summary Token revocation endpoint
+// @Param token formData string true "Access or refresh token to revoke"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Param client_id formData string false "Client ID when not using HTTP Basic authentication"
+// @Param client_secret formData string false "Client secret of confidential clients"
+// @Param client_assertion_type formData string false "urn:ietf:params:oauth:client-assertion-type:jwt-bearer for private_key_jwt and client_secret_jwt clients"
+// @Param client_assertion formData string false "JWT authenticating the client"
+// @Description Revoke an access or refresh token issued to the calling client (RFC 7009)
+// @Accept application/x-www-form-urlencoded
+// @Produce json
+// @Success 200 {object} interface{}
+// @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /token/revoke [post]
+// @Tags Tokens
+func (tc *TokenController) RevokeHandler(c *gin.Context) {
+	application, err := identifyClient(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client", "error_description": err.Error()})
+		return
+	}
+
+	var req token_dtos.RevocationRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	// the hint only decides which lookup is attempted first
+	revocations := []func(*entities.Application, string) (bool, error){
+		tc.revokeAccessToken,
+		tc.revokeRefreshToken,
+	}
+	if req.TokenTypeHint == core.RefreshTokenTypeHint {
+		slices.Reverse(revocations)
+	}
+	for _, revoke := range revocations {
+		found, err := revoke(application, req.Token)
+		if errors.Is(err, errUnauthorizedClient) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unauthorized_client", "error_description": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": "failed to revoke token"})
+			return
+		}
+		if found {
+			break
+		}
+	}
+
+	// unknown and already invalid tokens are not an error (RFC 7009 section 2.2)
+	c.Status(http.StatusOK)
+}
+
+func (tc *TokenController) revokeAccessToken(application *entities.Application, token string) (bool, error) {
+	payload, err := newTokenService().ValidateToken(token)
+	if err != nil {
+		return false, nil
+	}
+	if payload.ClientID != application.ClientID {
+		return true, errUnauthorizedClient
+	}
+	return true, (&entities.RevokedToken{}).Revoke(payload)
+}
+
+// Revoking a refresh token invalidates its whole family
+func (tc *TokenController) revokeRefreshToken(application *entities.Application, token string) (bool, error) {
+	refreshToken := (&entities.RefreshToken{}).LoadByToken(token)
+	if refreshToken == nil {
+		return false, nil
+	}
+	if refreshToken.ApplicationID != application.ID {
+		return true, errUnauthorizedClient
+	}
+	return true, refreshToken.RevokeFamily()
+}
+
+// Extracts the token from a "DPoP" Authorization header
+func dpopToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, core.DPoPTokenType) || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// Extracts the token from a "Bearer" Authorization header
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/keyloom/web-api/core"
@@ -16,7 +17,11 @@ var _ core.Controller = (*UserController)(nil)
 func (uc *UserController) RegisterRoutes(engine *gin.Engine) {
 	userGroup := engine.Group("/users")
 	{
-		userGroup.POST("/", uc.CreateHandler)
+		userGroup.POST("/", requireScopes(core.ManageUsersScope), uc.CreateHandler)
+		userGroup.GET("/", requireScopes(core.ViewUsersScope), uc.GetAllHandler)
+		userGroup.GET("/:id", requireScopes(core.ViewUsersScope), uc.GetByIDHandler)
+		userGroup.PUT("/:id", requireScopes(core.ManageUsersScope), uc.UpdateHandler)
+		userGroup.DELETE("/:id", requireScopes(core.ManageUsersScope), uc.DeleteHandler)
 	}
 }
 
@@ -27,9 +32,12 @@ func (uc *UserController) RegisterRoutes(engine *gin.Engine) {
 // @Produce json
 // @Success 200 {object} entities.User
 // @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
 // @Failure 500 {object} interface{}
 // @Router /users/ [post]
 // @Tags Users
+// @Security ApiKeyAuth
 func (uc *UserController) CreateHandler(c *gin.Context) {
 	var dto user_dtos.CreateUserDTO
 	if err := c.ShouldBindJSON(&dto); err != nil {
@@ -47,6 +55,9 @@ func (uc *UserController) CreateHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	entity.Name = dto.Name
+	entity.GivenName = dto.GivenName
+	entity.FamilyName = dto.FamilyName
 	err = entity.Save()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user", "details": err.Error()})
@@ -54,3 +65,184 @@ func (uc *UserController) CreateHandler(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, entity)
 }
+
+// @Summary Get all users with pagination
+// @Param limit query int false "Number of users to return" default(10)
+// @Param page query int false "Page number" default(1)
+// @Param email query string false "Part of the email, case insensitive"
+// @Param status query string false "active or disabled"
+// @Param created_after query int false "Only users created at or after this Unix timestamp"
+// @Param created_before query int false "Only users created before this Unix timestamp"
+// @Description Retrieve a paginated list of users, oldest first, optionally filtered by email, status and creation date
+// @Produce json
+// @Success 200 {array} entities.User
+// @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /users/ [get]
+// @Tags Users
+// @Security ApiKeyAuth
+func (uc *UserController) GetAllHandler(c *gin.Context) {
+	var filter user_dtos.UserFilterDTO
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	top, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || top <= 0 {
+		top = 10
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	users := (&entities.User{}).LoadFiltered(filter, top, page)
+	if users == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load users"})
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// @Summary Get user by ID
+// @Param id path string true "User ID"
+// @Description Retrieve a user by their ID
+// @Produce json
+// @Success 200 {object} entities.User
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
+// @Failure 404 {object} interface{}
+// @Router /users/{id} [get]
+// @Tags Users
+// @Security ApiKeyAuth
+func (uc *UserController) GetByIDHandler(c *gin.Context) {
+	user := (&entities.User{}).LoadByID(c.Param("id"))
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// @Summary Update an existing user
+// @Param id path string true "User ID"
+// @Param body body user_dtos.UpdateUserDTO true "User update data"
+// @Description Update the email, password, status or names of a user, omitted fields are kept. A new email must be verified again. Changing the password or disabling the user signs them out everywhere and revokes their tokens.
+// @Accept json
+// @Produce json
+// @Success 200 {object} entities.User
+// @Failure 400 {object} interface{}
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
+// @Failure 404 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /users/{id} [put]
+// @Tags Users
+// @Security ApiKeyAuth
+func (uc *UserController) UpdateHandler(c *gin.Context) {
+	var dto user_dtos.UpdateUserDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user := (&entities.User{}).LoadByID(c.Param("id"))
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if dto.Email != "" && dto.Email != user.Email {
+		err := user.SetEmail(dto.Email)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		user.EmailVerified = false
+	}
+	signOut := false
+	if dto.Password != "" {
+		err := user.SetPassword(dto.Password)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		signOut = true
+	}
+	if dto.Status != "" {
+		signOut = signOut || (dto.Status == core.UserStatusDisabled && !user.IsDisabled())
+		user.Status = dto.Status
+	}
+	if dto.Name != nil {
+		user.Name = *dto.Name
+	}
+	if dto.GivenName != nil {
+		user.GivenName = *dto.GivenName
+	}
+	if dto.FamilyName != nil {
+		user.FamilyName = *dto.FamilyName
+	}
+
+	err := user.Save()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+	if signOut {
+		err = signOutUser(user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke the tokens of the user"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// @Summary Delete a user
+// @Param id path string true "User ID"
+// @Description Delete a user along with their grants. The user is signed out everywhere and their tokens are revoked.
+// @Success 204 "No Content"
+// @Failure 401 {object} interface{}
+// @Failure 403 {object} interface{}
+// @Failure 404 {object} interface{}
+// @Failure 500 {object} interface{}
+// @Router /users/{id} [delete]
+// @Tags Users
+// @Security ApiKeyAuth
+func (uc *UserController) DeleteHandler(c *gin.Context) {
+	user := (&entities.User{}).LoadByID(c.Param("id"))
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	err := signOutUser(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke the tokens of the user"})
+		return
+	}
+	err = (&entities.Grant{}).DeleteByUser(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete the grants of the user"})
+		return
+	}
+	err = user.Delete()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Ends the browser sessions of the user and revokes every token issued on
+// their behalf
+func signOutUser(user *entities.User) error {
+	for _, session := range (&entities.Session{}).LoadByUser(user.ID) {
+		endSession(session)
+	}
+	err := (&entities.RefreshToken{}).RevokeByUser(user.ID)
+	if err != nil {
+		return err
+	}
+	return (&entities.RevokedToken{}).RevokeSubject(user.ID.Hex(), "")
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Client assertion type of JWTs authenticating clients (RFC 7523 section 2.2)
+var ClientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// Algorithms accepted for client assertions. Assertions of private_key_jwt
+// clients are signed with their keys, those of client_secret_jwt clients
+// with an HMAC of their secret.
+var ClientAssertionAsymmetricAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+var ClientAssertionHMACAlgorithms = []string{"HS256", "HS384", "HS512"}
+
+// Longest lifetime accepted for a client assertion, its jti is remembered
+// until it expires
+var ClientAssertionMaxLifetime = time.Hour
+
+// Remembers the jti of used client assertions and DPoP proofs so they
+// cannot be replayed
+type AssertionReplayCache interface {
+	// Records the jti and returns false if it was already used by the issuer,
+	// a client ID or a DPoP key thumbprint
+	Consume(issuer, jti string, expireAt time.Time) bool
+}
+
+// Verifies JWTs presented by clients to authenticate themselves
+type ClientAssertionVerifier struct {
+	// Returns the keys the assertion may be signed with, given its kid
+	Keys func(kid string) ([]jwt.VerificationKey, error)
+	// Algorithms the client may sign its assertions with
+	Algorithms []string
+	// URLs identifying the authorization server, one of them must be in aud
+	Audiences []string
+	Replay    AssertionReplayCache
+}
+
+// Returns the client the assertion claims to be issued by, without verifying it
+func ClientAssertionIssuer(assertion string) (string, error) {
+	token, _, err := jwt.NewParser().ParseUnverified(assertion, jwt.MapClaims{})
+	if err != nil {
+		return "", err
+	}
+	return token.Claims.GetIssuer()
+}
+
+// Verifies the assertion of the client: its signature, iss and sub must be
+// the client ID, aud must identify this server, exp is required and the
+// jti must not have been used before.
+func (v *ClientAssertionVerifier) Verify(assertion, clientID string) error {
+	keyfunc := func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		keys, err := v.Keys(kid)
+		if err != nil {
+			return nil, err
+		}
+		return jwt.VerificationKeySet{Keys: keys}, nil
+	}
+	token, err := jwt.Parse(assertion, keyfunc,
+		jwt.WithValidMethods(v.Algorithms),
+		jwt.WithIssuer(clientID),
+		jwt.WithSubject(clientID),
+		jwt.WithAudience(v.Audiences...),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil || !token.Valid {
+		return errors.New("invalid client assertion")
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return errors.New("the client assertion has no jti")
+	}
+	expireAt, err := claims.GetExpirationTime()
+	if err != nil {
+		return err
+	}
+	if time.Until(expireAt.Time) > ClientAssertionMaxLifetime {
+		return errors.New("the client assertion is valid for too long")
+	}
+	if !v.Replay.Consume(clientID, jti, expireAt.Time) {
+		return errors.New("the client assertion has already been used")
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"crypto"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	jwk_dtos "github.com/keyloom/web-api/dtos/jwk"
+)
+
+const testClientID = "test-client"
+const testTokenEndpoint = "https://keyloom.test/token"
+
+// Remembers used jti in memory
+type memoryReplayCache struct {
+	mutex sync.Mutex
+	used  map[string]bool
+}
+
+func (m *memoryReplayCache) Consume(issuer, jti string, expireAt time.Time) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.used == nil {
+		m.used = map[string]bool{}
+	}
+	if m.used[issuer+":"+jti] {
+		return false
+	}
+	m.used[issuer+":"+jti] = true
+	return true
+}
+
+// Builds a verifier resolving keys from the JWKS served by the stand-in, the
+// way private_key_jwt clients registered with a jwks_uri are verified
+func newTestAssertionVerifier(fetcher *JWKSFetcher, jwksURL string) *ClientAssertionVerifier {
+	keysByKid := func(keySet jwk_dtos.JWKSet, kid string) []jwt.VerificationKey {
+		keys := []jwt.VerificationKey{}
+		for _, jwk := range keySet.Keys {
+			if kid != "" && jwk.Kid != kid {
+				continue
+			}
+			key, err := ParseJWK(jwk)
+			if err == nil {
+				keys = append(keys, key)
+			}
+		}
+		return keys
+	}
+	return &ClientAssertionVerifier{
+		Keys: func(kid string) ([]jwt.VerificationKey, error) {
+			keySet, err := fetcher.Fetch(jwksURL, false)
+			if err != nil {
+				return nil, err
+			}
+			keys := keysByKid(keySet, kid)
+			if len(keys) == 0 {
+				keySet, err = fetcher.Fetch(jwksURL, true)
+				if err != nil {
+					return nil, err
+				}
+				keys = keysByKid(keySet, kid)
+			}
+			return keys, nil
+		},
+		Algorithms: ClientAssertionAsymmetricAlgorithms,
+		Audiences:  []string{testTokenEndpoint},
+		Replay:     &memoryReplayCache{},
+	}
+}
+
+func validAssertionClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss": testClientID,
+		"sub": testClientID,
+		"aud": testTokenEndpoint,
+		"jti": now.Format(time.RFC3339Nano),
+		"iat": now.Unix(),
+		"exp": now.Add(time.Minute).Unix(),
+	}
+}
+
+func signAssertion(t *testing.T, key crypto.Signer, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = kid
+	assertion, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return assertion
+}
+
+func TestClientAssertionVerifierAcceptsValidAssertions(t *testing.T) {
+	key, jwk := newTestSigningKey(t, "k1")
+	standIn := newJWKSStandIn(t, jwk_dtos.JWKSet{Keys: []jwk_dtos.JWK{jwk}})
+	verifier := newTestAssertionVerifier(newJWKSFetcher(http.DefaultTransport), standIn.server.URL)
+
+	err := verifier.Verify(signAssertion(t, key, "k1", validAssertionClaims()), testClientID)
+	if err != nil {
+		t.Fatalf("expected the assertion to be accepted: %v", err)
+	}
+}
+
+func TestClientAssertionVerifierRejectsInvalidClaims(t *testing.T) {
+	key, jwk := newTestSigningKey(t, "k1")
+	standIn := newJWKSStandIn(t, jwk_dtos.JWKSet{Keys: []jwk_dtos.JWK{jwk}})
+	verifier := newTestAssertionVerifier(newJWKSFetcher(http.DefaultTransport), standIn.server.URL)
+
+	tests := []struct {
+		name   string
+		modify func(jwt.MapClaims)
+	}{
+		{"wrong iss", func(claims jwt.MapClaims) { claims["iss"] = "other-client" }},
+		{"wrong sub", func(claims jwt.MapClaims) { claims["sub"] = "other-client" }},
+		{"wrong aud", func(claims jwt.MapClaims) { claims["aud"] = "https://other.test/token" }},
+		{"missing exp", func(claims jwt.MapClaims) { delete(claims, "exp") }},
+		{"expired", func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"overlong lifetime", func(claims jwt.MapClaims) {
+			claims["exp"] = time.Now().Add(ClientAssertionMaxLifetime + time.Minute).Unix()
+		}},
+		{"missing jti", func(claims jwt.MapClaims) { delete(claims, "jti") }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims := validAssertionClaims()
+			test.modify(claims)
+			err := verifier.Verify(signAssertion(t, key, "k1", claims), testClientID)
+			if err == nil {
+				t.Fatal("expected the assertion to be rejected")
+			}
+		})
+	}
+}
+
+func TestClientAssertionVerifierRejectsOtherKeys(t *testing.T) {
+	_, jwk := newTestSigningKey(t, "k1")
+	otherKey, _ := newTestSigningKey(t, "k1")
+	standIn := newJWKSStandIn(t, jwk_dtos.JWKSet{Keys: []jwk_dtos.JWK{jwk}})
+	verifier := newTestAssertionVerifier(newJWKSFetcher(http.DefaultTransport), standIn.server.URL)
+
+	err := verifier.Verify(signAssertion(t, otherKey, "k1", validAssertionClaims()), testClientID)
+	if err == nil {
+		t.Fatal("expected an assertion signed with another key to be rejected")
+	}
+}
+
+func TestClientAssertionVerifierRejectsReplays(t *testing.T) {
+	key, jwk := newTestSigningKey(t, "k1")
+	standIn := newJWKSStandIn(t, jwk_dtos.JWKSet{Keys: []jwk_dtos.JWK{jwk}})
+	verifier := newTestAssertionVerifier(newJWKSFetcher(http.DefaultTransport), standIn.server.URL)
+
+	assertion := signAssertion(t, key, "k1", validAssertionClaims())
+	if err := verifier.Verify(assertion, testClientID); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.Verify(assertion, testClientID); err == nil {
+		t.Fatal("expected the replayed assertion to be rejected")
+	}
+}
+
+func TestClientAssertionVerifierRefetchesOnUnknownKid(t *testing.T) {
+	_, first := newTestSigningKey(t, "k1")
+	rotatedKey, rotated := newTestSigningKey(t, "k2")
+	standIn := newJWKSStandIn(t, jwk_dtos.JWKSet{Keys: []jwk_dtos.JWK{first}})
+	fetcher := newJWKSFetcher(http.DefaultTransport)
+	verifier := newTestAssertionVerifier(fetcher, standIn.server.URL)
+
+	if _, err := fetcher.Fetch(standIn.server.URL, false); err != nil {
+		t.Fatal(err)
+	}
+	// the client rotates its keys once the cached copy passed the rate limit
+	standIn.keys.Store(jwk_dtos.JWKSet{Keys: []jwk_dtos.JWK{first, rotated}})
+	fetcher.cache[standIn.server.URL] = cachedJWKS{
+		keys:      fetcher.cache[standIn.server.URL].keys,
+		fetchedAt: time.Now().Add(-time.Minute),
+	}
+
+	err := verifier.Verify(signAssertion(t, rotatedKey, "k2", validAssertionClaims()), testClientID)
+	if err != nil {
+		t.Fatalf("expected the key set to be fetched again: %v", err)
+	}
+	if got := standIn.requests.Load(); got != 2 {
+		t.Fatalf("expected 2 requests, got %d", got)
+	}
+}
@@ -1,12 +1,154 @@
 package core
 
+import "time"
+
 var PasswordGrant = "password"
 var ClientCredentialsGrant = "client_credentials"
 var CodeGrant = "authorization_code"
 var RefreshTokenGrant = "refresh_token"
+var DeviceCodeGrant = "urn:ietf:params:oauth:grant-type:device_code"
+var TokenExchangeGrant = "urn:ietf:params:oauth:grant-type:token-exchange"
+var GrantTypes = []string{PasswordGrant, ClientCredentialsGrant, CodeGrant, RefreshTokenGrant, DeviceCodeGrant, TokenExchangeGrant}
+
+// Prefix of the identifiers generated for resource servers
+var ResourceIdentifierPrefix = "urn:keyloom:resource:"
+
+// Identifier of the default resource server representing the Keyloom Web
+// API, its scopes protect the admin endpoints
+var KeyloomAPIIdentifier = ResourceIdentifierPrefix + "keyloom-web-api"
+var ViewResourceServersScope = "keyloom:view:resource-servers"
+var ManageResourceServersScope = "keyloom:manage:resource-servers"
+var ViewApplicationsScope = "keyloom:view:applications"
+var ManageApplicationsScope = "keyloom:manage:applications"
+var ViewUsersScope = "keyloom:view:users"
+var ManageUsersScope = "keyloom:manage:users"
+var ViewGrantsScope = "keyloom:view:grants"
+var ManageGrantsScope = "keyloom:manage:grants"
+
+// Token type identifiers (RFC 8693 section 3)
+var AccessTokenType = "urn:ietf:params:oauth:token-type:access_token"
+var JWTTokenType = "urn:ietf:params:oauth:token-type:jwt"
+
+// typ header of JWT access tokens (RFC 9068 section 2.1)
+var AccessTokenJWTType = "at+jwt"
+
+// Token type hints (RFC 7009 and RFC 7662)
+var AccessTokenTypeHint = "access_token"
+var RefreshTokenTypeHint = "refresh_token"
+
+// OpenID Connect scopes, every application may request them
+var OpenIDScope = "openid"
+var ProfileScope = "profile"
+var EmailScope = "email"
+var OpenIDScopes = []string{OpenIDScope, ProfileScope, EmailScope}
+
+// Descriptions of the OpenID Connect scopes shown on the consent page
+var OpenIDScopeDescriptions = map[string]string{
+	OpenIDScope:  "Sign you in with your Keyloom account",
+	ProfileScope: "View your name",
+	EmailScope:   "View your email address",
+}
+
+// Client authentication methods at the token endpoint
+var ClientSecretBasicAuthMethod = "client_secret_basic"
+var ClientSecretPostAuthMethod = "client_secret_post"
+var NoneAuthMethod = "none"
+var PrivateKeyJWTAuthMethod = "private_key_jwt"
+var ClientSecretJWTAuthMethod = "client_secret_jwt"
+var TLSClientAuthMethod = "tls_client_auth"
+var SelfSignedTLSClientAuthMethod = "self_signed_tls_client_auth"
+var TokenEndpointAuthMethods = []string{ClientSecretBasicAuthMethod, ClientSecretPostAuthMethod, PrivateKeyJWTAuthMethod, ClientSecretJWTAuthMethod, TLSClientAuthMethod, SelfSignedTLSClientAuthMethod, NoneAuthMethod}
+
+// Application types
+var SPAApplicationType = "spa"
+var NativeApplicationType = "native"
+var WebApplicationType = "web"
+var M2MApplicationType = "m2m"
+var ApplicationTypes = []string{SPAApplicationType, NativeApplicationType, WebApplicationType, M2MApplicationType}
+
+// What each application type may use. Public clients (SPA and native) run
+// on user devices and cannot keep a secret, so they never authenticate and
+// cannot use grants without a user.
+var ApplicationTypeGrantTypes = map[string][]string{
+	SPAApplicationType:    {CodeGrant, RefreshTokenGrant, PasswordGrant},
+	NativeApplicationType: {CodeGrant, RefreshTokenGrant, DeviceCodeGrant, PasswordGrant},
+	WebApplicationType:    {CodeGrant, RefreshTokenGrant, PasswordGrant, ClientCredentialsGrant, TokenExchangeGrant},
+	M2MApplicationType:    {ClientCredentialsGrant, TokenExchangeGrant},
+}
+var ApplicationTypeAuthMethods = map[string][]string{
+	SPAApplicationType:    {NoneAuthMethod},
+	NativeApplicationType: {NoneAuthMethod},
+	WebApplicationType:    {ClientSecretBasicAuthMethod, ClientSecretPostAuthMethod, PrivateKeyJWTAuthMethod, ClientSecretJWTAuthMethod, TLSClientAuthMethod, SelfSignedTLSClientAuthMethod},
+	M2MApplicationType:    {ClientSecretBasicAuthMethod, ClientSecretPostAuthMethod, PrivateKeyJWTAuthMethod, ClientSecretJWTAuthMethod, TLSClientAuthMethod, SelfSignedTLSClientAuthMethod},
+}
+
+// Grant types given to new applications of each type
+var ApplicationTypeDefaultGrantTypes = map[string][]string{
+	SPAApplicationType:    {CodeGrant, RefreshTokenGrant},
+	NativeApplicationType: {CodeGrant, RefreshTokenGrant, DeviceCodeGrant},
+	WebApplicationType:    {CodeGrant, RefreshTokenGrant},
+	M2MApplicationType:    {ClientCredentialsGrant},
+}
+
+// Prompt values (OpenID Connect Core 3.1.2.1): consent forces the consent
+// page, login forces the user to sign in again and none forbids any page
+var ConsentPrompt = "consent"
+var LoginPrompt = "login"
+var NonePrompt = "none"
+
+// Authorization endpoint constants
+var CodeResponseType = "code"
+var CodeChallengeMethodS256 = "S256"
+var AuthorizationRequestLifetime = 10 * time.Minute
+var PushedAuthorizationRequestLifetime = 90 * time.Second
+var AuthorizationCodeLifetime = 1 * time.Minute
+
+// Prefix of the request_uri of pushed authorization requests (RFC 9126)
+var RequestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+
+// User account statuses, disabled users cannot sign in
+var UserStatusActive = "active"
+var UserStatusDisabled = "disabled"
+
+// Browser session constants
+var SessionCookieName = "keyloom_session"
+var SessionLifetime = 24 * time.Hour
+
+// Logout notification constants (OpenID Connect Back-Channel Logout 1.0)
+var BackchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+var LogoutTokenType = "logout+jwt"
+var LogoutTokenLifetime = 2 * time.Minute
+var LogoutDeliveryTimeout = 10 * time.Second
+var LogoutDeliveryMaxAttempts = 5
+var LogoutDeliveryRetryDelay = 30 * time.Second // doubled after each failed attempt
+var LogoutDeliveryRetention = 7 * 24 * time.Hour
+var LogoutDeliveryStatusPending = "pending"
+var LogoutDeliveryStatusDelivered = "delivered"
+var LogoutDeliveryStatusFailed = "failed"
+
+// Device authorization constants (RFC 8628)
+var DeviceCodeLifetime = 10 * time.Minute
+var DevicePollingInterval = 5 // in seconds
+var DeviceCodeStatusPending = "pending"
+var DeviceCodeStatusApproved = "approved"
+var DeviceCodeStatusDenied = "denied"
 
 // Migration change constants
 var MigrationChangeCreateDefaultAdminUser = "create:default_admin_user"
 var MigrationChangeCreateDefaultResourceServer = "create:default_resource_server"
 var MigrationChangeCreateDefaultApplication = "create:default_application"
 var MigrationChangeCreateDefaultGrant = "create:default_grant"
+var MigrationChangeCreateAuthorizationIndexes = "create:authorization_indexes"
+var MigrationChangeCreateRefreshTokenIndexes = "create:refresh_token_indexes"
+var MigrationChangeCreateRevokedTokenIndexes = "create:revoked_token_indexes"
+var MigrationChangeCreateDeviceCodeIndexes = "create:device_code_indexes"
+var MigrationChangeUpdateDefaultGrantScopes = "update:default_grant_scopes"
+var MigrationChangeMigrateResourceServerIdentifiers = "update:resource_server_identifiers"
+var MigrationChangeUpdateDefaultResourceServerScopes = "update:default_resource_server_scopes"
+var MigrationChangeHashClientSecrets = "update:hash_client_secrets"
+var MigrationChangeSetApplicationTypes = "update:application_types"
+var MigrationChangeCreateClientAssertionIndexes = "create:client_assertion_indexes"
+var MigrationChangeCreateDPoPProofIndexes = "create:dpop_proof_indexes"
+var MigrationChangeCreateSessionIndexes = "create:session_indexes"
+var MigrationChangeCreateLogoutDeliveryIndexes = "create:logout_delivery_indexes"
+var MigrationChangeSetUserStatuses = "update:user_statuses"
@@ -0,0 +1,195 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	jwk_dtos "github.com/keyloom/web-api/dtos/jwk"
+	token_dtos "github.com/keyloom/web-api/dtos/token"
+)
+
+// Token type and JWT type of DPoP (RFC 9449)
+var DPoPTokenType = "DPoP"
+var DPoPProofType = "dpop+jwt"
+
+// Algorithms accepted for DPoP proofs
+var DPoPAlgorithms = ClientAssertionAsymmetricAlgorithms
+
+// How far the iat of a proof may be from the current time
+var DPoPProofLifetime = 5 * time.Minute
+
+// Nonces are valid for the window they were issued in and the next one
+var DPoPNonceWindow = 5 * time.Minute
+
+// The proof is valid but was created without the current server nonce.
+// The client must retry with the nonce of the DPoP-Nonce header.
+var ErrUseDPoPNonce = errors.New("a fresh DPoP nonce is required")
+
+// Proof that is malformed, wrongly signed or does not match the request
+var ErrInvalidDPoPProof = errors.New("invalid DPoP proof")
+
+// Verifies DPoP proofs sent to the token endpoint or to resource servers
+type DPoPVerifier struct {
+	// Remembers the jti of used proofs
+	Replay AssertionReplayCache
+	// Require proofs to carry a nonce issued by NewDPoPNonce
+	RequireNonce bool
+}
+
+// Verifies the proof of a request and returns the JWK thumbprint of the key
+// it was signed with. The access token is only given to resource servers,
+// its hash must then be in the ath claim.
+func (v *DPoPVerifier) VerifyProof(proof, method, requestURL, accessToken string) (string, error) {
+	var jwk jwk_dtos.JWK
+	keyfunc := func(token *jwt.Token) (any, error) {
+		if typ, _ := token.Header["typ"].(string); typ != DPoPProofType {
+			return nil, errors.New("unexpected typ")
+		}
+		encoded, err := json.Marshal(token.Header["jwk"])
+		if err != nil {
+			return nil, err
+		}
+		var header map[string]any
+		if err := json.Unmarshal(encoded, &header); err != nil || header == nil {
+			return nil, errors.New("missing jwk")
+		}
+		// a private key in the header would be a client mistake worth refusing
+		if _, private := header["d"]; private {
+			return nil, errors.New("the jwk must be a public key")
+		}
+		if err := json.Unmarshal(encoded, &jwk); err != nil {
+			return nil, err
+		}
+		return ParseJWK(jwk)
+	}
+	token, err := jwt.Parse(proof, keyfunc, jwt.WithValidMethods(DPoPAlgorithms), jwt.WithoutClaimsValidation())
+	if err != nil || !token.Valid {
+		return "", ErrInvalidDPoPProof
+	}
+	claims := token.Claims.(jwt.MapClaims)
+
+	htm, _ := claims["htm"].(string)
+	htu, _ := claims["htu"].(string)
+	if htm != method || !sameTargetURI(htu, requestURL) {
+		return "", fmt.Errorf("%w: htm or htu does not match the request", ErrInvalidDPoPProof)
+	}
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return "", fmt.Errorf("%w: iat is required", ErrInvalidDPoPProof)
+	}
+	if age := time.Since(issuedAt.Time); age > DPoPProofLifetime || age < -DPoPProofLifetime {
+		return "", fmt.Errorf("%w: the proof is too old or too far in the future", ErrInvalidDPoPProof)
+	}
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return "", fmt.Errorf("%w: jti is required", ErrInvalidDPoPProof)
+	}
+	if accessToken != "" {
+		digest := sha256.Sum256([]byte(accessToken))
+		ath, _ := claims["ath"].(string)
+		if subtle.ConstantTimeCompare([]byte(ath), []byte(base64.RawURLEncoding.EncodeToString(digest[:]))) != 1 {
+			return "", fmt.Errorf("%w: ath does not match the access token", ErrInvalidDPoPProof)
+		}
+	}
+
+	nonce, _ := claims["nonce"].(string)
+	if nonce != "" || v.RequireNonce {
+		if !CheckDPoPNonce(nonce) {
+			return "", ErrUseDPoPNonce
+		}
+	}
+
+	thumbprint, err := JWKThumbprint(jwk)
+	if err != nil {
+		return "", ErrInvalidDPoPProof
+	}
+	// the proof is only consumed once everything else checked out
+	if !v.Replay.Consume(thumbprint, jti, issuedAt.Add(2*DPoPProofLifetime)) {
+		return "", fmt.Errorf("%w: the proof has already been used", ErrInvalidDPoPProof)
+	}
+	return thumbprint, nil
+}
+
+// Checks a request presenting a DPoP-bound access token to a resource
+// server: the Authorization header must use the DPoP scheme and the DPoP
+// header must hold a proof for the request, signed with the key the token
+// is bound to. The request URL is given since proxies may have rewritten it.
+func (v *DPoPVerifier) VerifyResourceRequest(r *http.Request, requestURL string, payload *token_dtos.JWTPayload) error {
+	scheme, accessToken, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, DPoPTokenType) || accessToken == "" {
+		return fmt.Errorf("%w: the DPoP authorization scheme is required", ErrInvalidDPoPProof)
+	}
+	proofs := r.Header.Values("DPoP")
+	if len(proofs) != 1 {
+		return fmt.Errorf("%w: exactly one DPoP header is required", ErrInvalidDPoPProof)
+	}
+	thumbprint, err := v.VerifyProof(proofs[0], r.Method, requestURL, strings.TrimSpace(accessToken))
+	if err != nil {
+		return err
+	}
+	if payload.Cnf == nil || subtle.ConstantTimeCompare([]byte(payload.Cnf.Jkt), []byte(thumbprint)) != 1 {
+		return fmt.Errorf("%w: the access token is bound to another key", ErrInvalidDPoPProof)
+	}
+	return nil
+}
+
+// Compares URIs without query and fragment (RFC 9449 section 4.3)
+func sameTargetURI(htu, requestURL string) bool {
+	proofURL, err := url.Parse(htu)
+	if err != nil {
+		return false
+	}
+	target, err := url.Parse(requestURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(proofURL.Scheme, target.Scheme) &&
+		strings.EqualFold(proofURL.Host, target.Host) &&
+		proofURL.EscapedPath() == target.EscapedPath()
+}
+
+// Issues a nonce for DPoP proofs. Nonces are not stored: they carry the
+// time window they were issued in, authenticated with TOKEN_SECRET_KEY.
+func NewDPoPNonce() (string, error) {
+	window := time.Now().Unix() / int64(DPoPNonceWindow.Seconds())
+	return dpopNonce(window)
+}
+
+// Checks a nonce issued by NewDPoPNonce
+func CheckDPoPNonce(nonce string) bool {
+	encodedWindow, _, found := strings.Cut(nonce, ".")
+	if !found {
+		return false
+	}
+	window, err := strconv.ParseInt(encodedWindow, 10, 64)
+	if err != nil {
+		return false
+	}
+	current := time.Now().Unix() / int64(DPoPNonceWindow.Seconds())
+	if window != current && window != current-1 {
+		return false
+	}
+	expected, err := dpopNonce(window)
+	return err == nil && hmac.Equal([]byte(expected), []byte(nonce))
+}
+
+func dpopNonce(window int64) (string, error) {
+	config, err := (&EnvManager{}).GetTokenConfig()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(config.SecretKey))
+	mac.Write([]byte("dpop-nonce:" + strconv.FormatInt(window, 10)))
+	return strconv.FormatInt(window, 10) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
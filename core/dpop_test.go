@@ -0,0 +1,228 @@
+package core
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testDPoPURL = "https://keyloom.test/token"
+
+func setTestTokenConfig(t *testing.T) {
+	t.Setenv("TOKEN_SECRET_KEY", "dpop-test-secret")
+	t.Setenv("TOKEN_ISSUER", "https://keyloom.test")
+	t.Setenv("TOKEN_AUDIENCE", "keyloom")
+	t.Setenv("TOKEN_DURATION", "15")
+}
+
+func validProofClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"htm": "POST",
+		"htu": testDPoPURL,
+		"iat": time.Now().Unix(),
+		"jti": "proof-" + time.Now().Format(time.RFC3339Nano),
+	}
+}
+
+// Signs a DPoP proof with the key, publishing the given jwk in its header
+func signProof(t *testing.T, key crypto.Signer, jwk any, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["typ"] = DPoPProofType
+	token.Header["jwk"] = jwk
+	proof, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+func TestVerifyProof(t *testing.T) {
+	setTestTokenConfig(t)
+	key, jwk := newTestSigningKey(t, "")
+	thumbprint, err := JWKThumbprint(jwk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("access-token"))
+	ath := base64.RawURLEncoding.EncodeToString(digest[:])
+
+	// the public jwk along with the private part of the key
+	encoded, err := json.Marshal(jwk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var privateJWK map[string]any
+	if err := json.Unmarshal(encoded, &privateJWK); err != nil {
+		t.Fatal(err)
+	}
+	privateJWK["d"] = "private"
+
+	tests := []struct {
+		name        string
+		jwk         any
+		claims      func(jwt.MapClaims)
+		url         string
+		accessToken string
+		err         error
+	}{
+		{"valid proof", jwk, func(jwt.MapClaims) {}, testDPoPURL, "", nil},
+		{"query and fragment are ignored", jwk, func(jwt.MapClaims) {}, testDPoPURL + "?a=b#c", "", nil},
+		{"wrong htm", jwk, func(c jwt.MapClaims) { c["htm"] = "GET" }, testDPoPURL, "", ErrInvalidDPoPProof},
+		{"wrong htu", jwk, func(c jwt.MapClaims) { c["htu"] = "https://keyloom.test/userinfo" }, testDPoPURL, "", ErrInvalidDPoPProof},
+		{"matching ath", jwk, func(c jwt.MapClaims) { c["ath"] = ath }, testDPoPURL, "access-token", nil},
+		{"ath of another token", jwk, func(c jwt.MapClaims) { c["ath"] = ath }, testDPoPURL, "other-token", ErrInvalidDPoPProof},
+		{"missing ath", jwk, func(jwt.MapClaims) {}, testDPoPURL, "access-token", ErrInvalidDPoPProof},
+		{"stale iat", jwk, func(c jwt.MapClaims) { c["iat"] = time.Now().Add(-DPoPProofLifetime - time.Minute).Unix() }, testDPoPURL, "", ErrInvalidDPoPProof},
+		{"iat in the future", jwk, func(c jwt.MapClaims) { c["iat"] = time.Now().Add(DPoPProofLifetime + time.Minute).Unix() }, testDPoPURL, "", ErrInvalidDPoPProof},
+		{"missing iat", jwk, func(c jwt.MapClaims) { delete(c, "iat") }, testDPoPURL, "", ErrInvalidDPoPProof},
+		{"missing jti", jwk, func(c jwt.MapClaims) { delete(c, "jti") }, testDPoPURL, "", ErrInvalidDPoPProof},
+		{"private key in the jwk header", privateJWK, func(jwt.MapClaims) {}, testDPoPURL, "", ErrInvalidDPoPProof},
+		{"missing jwk header", nil, func(jwt.MapClaims) {}, testDPoPURL, "", ErrInvalidDPoPProof},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims := validProofClaims()
+			test.claims(claims)
+			proof := signProof(t, key, test.jwk, claims)
+			verifier := &DPoPVerifier{Replay: &memoryReplayCache{}}
+
+			got, err := verifier.VerifyProof(proof, "POST", test.url, test.accessToken)
+			if test.err == nil {
+				if err != nil {
+					t.Fatalf("expected the proof to be accepted: %v", err)
+				}
+				if got != thumbprint {
+					t.Fatalf("expected thumbprint %s, got %s", thumbprint, got)
+				}
+				return
+			}
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestVerifyProofRejectsOtherKeys(t *testing.T) {
+	key, _ := newTestSigningKey(t, "")
+	_, otherJWK := newTestSigningKey(t, "")
+	proof := signProof(t, key, otherJWK, validProofClaims())
+
+	_, err := (&DPoPVerifier{Replay: &memoryReplayCache{}}).VerifyProof(proof, "POST", testDPoPURL, "")
+	if !errors.Is(err, ErrInvalidDPoPProof) {
+		t.Fatalf("expected %v, got %v", ErrInvalidDPoPProof, err)
+	}
+}
+
+func TestVerifyProofRejectsReplays(t *testing.T) {
+	key, jwk := newTestSigningKey(t, "")
+	proof := signProof(t, key, jwk, validProofClaims())
+	verifier := &DPoPVerifier{Replay: &memoryReplayCache{}}
+
+	if _, err := verifier.VerifyProof(proof, "POST", testDPoPURL, ""); err != nil {
+		t.Fatalf("expected the first use to be accepted: %v", err)
+	}
+	if _, err := verifier.VerifyProof(proof, "POST", testDPoPURL, ""); !errors.Is(err, ErrInvalidDPoPProof) {
+		t.Fatalf("expected the replay to be rejected, got %v", err)
+	}
+}
+
+func TestVerifyProofRequiresNonce(t *testing.T) {
+	setTestTokenConfig(t)
+	key, jwk := newTestSigningKey(t, "")
+	verifier := &DPoPVerifier{Replay: &memoryReplayCache{}, RequireNonce: true}
+
+	_, err := verifier.VerifyProof(signProof(t, key, jwk, validProofClaims()), "POST", testDPoPURL, "")
+	if !errors.Is(err, ErrUseDPoPNonce) {
+		t.Fatalf("expected %v without nonce, got %v", ErrUseDPoPNonce, err)
+	}
+	nonce, err := NewDPoPNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := validProofClaims()
+	claims["nonce"] = nonce
+	if _, err := verifier.VerifyProof(signProof(t, key, jwk, claims), "POST", testDPoPURL, ""); err != nil {
+		t.Fatalf("expected the proof with a fresh nonce to be accepted: %v", err)
+	}
+}
+
+func TestSameTargetURI(t *testing.T) {
+	tests := []struct {
+		name       string
+		htu        string
+		requestURL string
+		same       bool
+	}{
+		{"identical", "https://keyloom.test/token", "https://keyloom.test/token", true},
+		{"query and fragment ignored", "https://keyloom.test/token?a=b#c", "https://keyloom.test/token?c=d", true},
+		{"scheme and host are case-insensitive", "HTTPS://Keyloom.Test/token", "https://keyloom.test/token", true},
+		{"path is case-sensitive", "https://keyloom.test/Token", "https://keyloom.test/token", false},
+		{"other path", "https://keyloom.test/userinfo", "https://keyloom.test/token", false},
+		{"other host", "https://other.test/token", "https://keyloom.test/token", false},
+		{"other scheme", "http://keyloom.test/token", "https://keyloom.test/token", false},
+		{"other port", "https://keyloom.test:8443/token", "https://keyloom.test/token", false},
+		{"invalid htu", "https://keyloom.test/%zz", "https://keyloom.test/token", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := sameTargetURI(test.htu, test.requestURL); got != test.same {
+				t.Fatalf("expected %v, got %v", test.same, got)
+			}
+		})
+	}
+}
+
+func TestCheckDPoPNonce(t *testing.T) {
+	setTestTokenConfig(t)
+	current := time.Now().Unix() / int64(DPoPNonceWindow.Seconds())
+	nonceOf := func(window int64) string {
+		nonce, err := dpopNonce(window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return nonce
+	}
+	fresh, err := NewDPoPNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	window, mac, _ := strings.Cut(nonceOf(current), ".")
+
+	tests := []struct {
+		name  string
+		nonce string
+		valid bool
+	}{
+		{"fresh nonce", fresh, true},
+		{"previous window", nonceOf(current - 1), true},
+		{"expired window", nonceOf(current - 2), false},
+		{"future window", nonceOf(current + 1), false},
+		{"tampered mac", window + "." + strings.ToUpper(mac), false},
+		{"mac of another window", strconv.FormatInt(current-1, 10) + "." + mac, false},
+		{"malformed", "nonce", false},
+		{"empty", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := CheckDPoPNonce(test.nonce); got != test.valid {
+				t.Fatalf("expected %v, got %v", test.valid, got)
+			}
+		})
+	}
+
+	// nonces are authenticated with TOKEN_SECRET_KEY
+	t.Setenv("TOKEN_SECRET_KEY", "another-secret")
+	if CheckDPoPNonce(fresh) {
+		t.Fatal("expected a nonce issued with another secret to be rejected")
+	}
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	"os"
+	"slices"
 
 	envmanager_dtos "github.com/keyloom/web-api/dtos/env-manager"
 )
@@ -74,11 +75,18 @@ func (e *EnvManager) GetTokenConfig() (envmanager_dtos.TokenConfig, error) {
 	var duration int
 	fmt.Sscanf(values[3], "%d", &duration)
 
+	// REFRESH_TOKEN_DURATION is optional and defaults to 30 days
+	refreshDuration := 30 * 24 * 60
+	if value := os.Getenv("REFRESH_TOKEN_DURATION"); value != "" {
+		fmt.Sscanf(value, "%d", &refreshDuration)
+	}
+
 	tokenConfig := envmanager_dtos.TokenConfig{
-		SecretKey:     values[0],
-		Issuer:        values[1],
-		Audience:      values[2],
-		TokenDuration: duration,
+		SecretKey:            values[0],
+		Issuer:               values[1],
+		Audience:             values[2],
+		TokenDuration:        duration,
+		RefreshTokenDuration: refreshDuration,
 	}
 	return tokenConfig, nil
 }
@@ -98,3 +106,49 @@ func (e *EnvManager) GetAdminUserConfig() (envmanager_dtos.AdminUserConfig, erro
 	}
 	return adminUserConfig, nil
 }
+
+func (e *EnvManager) GetSigningKeyConfig() (envmanager_dtos.SigningKeyConfig, error) {
+	// SIGNING_KEY_ALGORITHM is optional and defaults to RS256
+	algorithm := os.Getenv("SIGNING_KEY_ALGORITHM")
+	if algorithm == "" {
+		algorithm = SigningAlgorithmRS256
+	}
+	if !slices.Contains(SigningAlgorithms, algorithm) {
+		return envmanager_dtos.SigningKeyConfig{}, fmt.Errorf("unsupported SIGNING_KEY_ALGORITHM: %s", algorithm)
+	}
+
+	// SIGNING_KEY_ROTATION_INTERVAL is optional and defaults to 30 days
+	rotationInterval := 30 * 24 * 60
+	if value := os.Getenv("SIGNING_KEY_ROTATION_INTERVAL"); value != "" {
+		fmt.Sscanf(value, "%d", &rotationInterval)
+	}
+
+	signingKeyConfig := envmanager_dtos.SigningKeyConfig{
+		Algorithm:        algorithm,
+		RotationInterval: rotationInterval,
+	}
+	return signingKeyConfig, nil
+}
+
+func (e *EnvManager) GetRegistrationConfig() envmanager_dtos.RegistrationConfig {
+	// REGISTRATION_INITIAL_ACCESS_TOKEN is optional, registration is open without it.
+	// REGISTRATION_ALLOW_PRIVATE_URLS is optional and defaults to false.
+	registrationConfig := envmanager_dtos.RegistrationConfig{
+		InitialAccessToken: os.Getenv("REGISTRATION_INITIAL_ACCESS_TOKEN"),
+		AllowPrivateURLs:   os.Getenv("REGISTRATION_ALLOW_PRIVATE_URLS") == "true",
+	}
+	return registrationConfig
+}
+
+func (e *EnvManager) GetTLSConfig() (envmanager_dtos.TLSConfig, error) {
+	// TLS is optional, the server certificate and key go together
+	tlsConfig := envmanager_dtos.TLSConfig{
+		CertFile:     os.Getenv("TLS_CERT_FILE"),
+		KeyFile:      os.Getenv("TLS_KEY_FILE"),
+		ClientCAFile: os.Getenv("TLS_CLIENT_CA_FILE"),
+	}
+	if (tlsConfig.CertFile == "") != (tlsConfig.KeyFile == "") {
+		return envmanager_dtos.TLSConfig{}, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	return tlsConfig, nil
+}
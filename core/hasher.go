@@ -1,6 +1,11 @@
 package core
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 type Hasher struct{}
 
@@ -21,3 +26,10 @@ func (h *Hasher) Compare(hashedPassword, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
 }
+
+// Returns the hex encoded SHA-256 digest of the given value.
+// Used for high entropy tokens that must be looked up by their hash.
+func (h *Hasher) Digest(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
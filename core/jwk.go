@@ -0,0 +1,140 @@
+package core
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	jwk_dtos "github.com/keyloom/web-api/dtos/jwk"
+)
+
+// Converts a public key to its JSON Web Key representation
+func NewJWK(publicKey crypto.PublicKey, kid, algorithm string) (jwk_dtos.JWK, error) {
+	encode := base64.RawURLEncoding.EncodeToString
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return jwk_dtos.JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Kid: kid,
+			Alg: algorithm,
+			N:   encode(key.N.Bytes()),
+			E:   encode(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		ecdhKey, err := key.ECDH()
+		if err != nil {
+			return jwk_dtos.JWK{}, err
+		}
+		// uncompressed point encoding: 0x04 || X || Y
+		point := ecdhKey.Bytes()
+		size := (len(point) - 1) / 2
+		return jwk_dtos.JWK{
+			Kty: "EC",
+			Use: "sig",
+			Kid: kid,
+			Alg: algorithm,
+			Crv: key.Curve.Params().Name,
+			X:   encode(point[1 : 1+size]),
+			Y:   encode(point[1+size:]),
+		}, nil
+	case ed25519.PublicKey:
+		return jwk_dtos.JWK{
+			Kty: "OKP",
+			Use: "sig",
+			Kid: kid,
+			Alg: algorithm,
+			Crv: "Ed25519",
+			X:   encode(key),
+		}, nil
+	default:
+		return jwk_dtos.JWK{}, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+// Converts a JSON Web Key to the public key it represents
+func ParseJWK(jwk jwk_dtos.JWK) (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) < 256 || !exponent.IsInt64() || exponent.Int64() < 3 {
+			return nil, errors.New("invalid or too small RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", jwk.Crv)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC key coordinates")
+		}
+		// uncompressed point encoding: 0x04 || X || Y
+		point := append(append([]byte{4}, x...), y...)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve: %s", jwk.Crv)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", jwk.Kty)
+	}
+}
+
+// Computes the JWK thumbprint of a public key (RFC 7638): the base64url
+// encoded SHA-256 digest of its required members in lexicographic order
+func JWKThumbprint(jwk jwk_dtos.JWK) (string, error) {
+	var members string
+	switch jwk.Kty {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	case "EC":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, jwk.Crv, jwk.X, jwk.Y)
+	case "OKP":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, jwk.Crv, jwk.X)
+	default:
+		return "", fmt.Errorf("unsupported key type: %s", jwk.Kty)
+	}
+	digest := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(digest[:]), nil
+}
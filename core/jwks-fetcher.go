@@ -0,0 +1,109 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	jwk_dtos "github.com/keyloom/web-api/dtos/jwk"
+)
+
+// How long fetched key sets are reused before they are fetched again
+var JWKSCacheDuration = 5 * time.Minute
+
+// Most key sets kept in the cache, the oldest one is evicted first
+var JWKSCacheSize = 1000
+
+type cachedJWKS struct {
+	keys      jwk_dtos.JWKSet
+	fetchedAt time.Time
+}
+
+// Fetches the JSON Web Key Sets published by clients and caches them
+type JWKSFetcher struct {
+	mutex  sync.Mutex
+	cache  map[string]cachedJWKS
+	client *http.Client
+}
+
+// Shared fetcher so the cache is kept between requests
+var jwksFetcher = newJWKSFetcher(outboundTransport)
+
+// Redirects are not followed: keys are only fetched from the URL the
+// client registered
+func newJWKSFetcher(transport http.RoundTripper) *JWKSFetcher {
+	return &JWKSFetcher{
+		cache: map[string]cachedJWKS{},
+		client: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Returns the key set published at the URL. A cached copy is used unless
+// it is outdated or refresh is set, e.g. because a key was not found in it.
+func FetchJWKS(url string, refresh bool) (jwk_dtos.JWKSet, error) {
+	return jwksFetcher.Fetch(url, refresh)
+}
+
+func (f *JWKSFetcher) Fetch(url string, refresh bool) (jwk_dtos.JWKSet, error) {
+	f.mutex.Lock()
+	cached, ok := f.cache[url]
+	f.mutex.Unlock()
+	// refreshes are rate limited so unknown kids cannot trigger a fetch each time
+	if ok && (time.Since(cached.fetchedAt) < 10*time.Second || (!refresh && time.Since(cached.fetchedAt) < JWKSCacheDuration)) {
+		return cached.keys, nil
+	}
+
+	response, err := f.client.Get(url)
+	if err != nil {
+		return jwk_dtos.JWKSet{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return jwk_dtos.JWKSet{}, fmt.Errorf("unexpected status fetching JWKS: %d", response.StatusCode)
+	}
+
+	var keys jwk_dtos.JWKSet
+	err = json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(&keys)
+	if err != nil {
+		return jwk_dtos.JWKSet{}, err
+	}
+	if len(keys.Keys) == 0 {
+		return jwk_dtos.JWKSet{}, errors.New("the JWKS does not contain any key")
+	}
+
+	f.store(url, keys)
+	return keys, nil
+}
+
+// Caches the key set of the URL. When the cache is full outdated key sets
+// are dropped, then the oldest one if it is still full.
+func (f *JWKSFetcher) store(url string, keys jwk_dtos.JWKSet) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if _, ok := f.cache[url]; !ok && len(f.cache) >= JWKSCacheSize {
+		oldest := ""
+		for cachedURL, cached := range f.cache {
+			if time.Since(cached.fetchedAt) >= JWKSCacheDuration {
+				delete(f.cache, cachedURL)
+				continue
+			}
+			if oldest == "" || cached.fetchedAt.Before(f.cache[oldest].fetchedAt) {
+				oldest = cachedURL
+			}
+		}
+		if len(f.cache) >= JWKSCacheSize {
+			delete(f.cache, oldest)
+		}
+	}
+	f.cache[url] = cachedJWKS{keys: keys, fetchedAt: time.Now()}
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"crypto"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jwk_dtos "github.com/keyloom/web-api/dtos/jwk"
+)
+
+// Serves a key set that tests can replace, counting the requests
+type jwksStandIn struct {
+	server   *httptest.Server
+	keys     atomic.Value
+	requests atomic.Int32
+}
+
+func newJWKSStandIn(t *testing.T, keys jwk_dtos.JWKSet) *jwksStandIn {
+	t.Helper()
+	standIn := &jwksStandIn{}
+	standIn.keys.Store(keys)
+	standIn.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		standIn.requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(standIn.keys.Load().(jwk_dtos.JWKSet))
+	}))
+	t.Cleanup(standIn.server.Close)
+	return standIn
+}
+
+// Generates an ES256 key pair and its public JWK
+func newTestSigningKey(t *testing.T, kid string) (crypto.Signer, jwk_dtos.JWK) {
+	t.Helper()
+	privateKey, err := GenerateSigningKey(SigningAlgorithmES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk, err := NewJWK(privateKey.Public(), kid, SigningAlgorithmES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privateKey, jwk
+}
+
+func TestJWKSFetcherCachesKeySets(t *testing.T) {
+	_, jwk := newTestSigningKey(t, "k1")
+	standIn := newJWKSStandIn(t, jwk_dtos.JWKSet{Keys: []jwk_dtos.JWK{jwk}})
+	fetcher := newJWKSFetcher(http.DefaultTransport)
+
+	for range 3 {
+		keys, err := fetcher.Fetch(standIn.server.URL, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys.Keys) != 1 || keys.Keys[0].Kid != "k1" {
+			t.Fatalf("unexpected key set: %+v", keys)
+		}
+	}
+	if got := standIn.requests.Load(); got != 1 {
+		t.Fatalf("expected 1 request, got %d", got)
+	}
+}
+
+func TestJWKSFetcherRefetchesOnUnknownKid(t *testing.T) {
+	_, first := newTestSigningKey(t, "k1")
+	_, second := newTestSigningKey(t, "k2")
+	standIn := newJWKSStandIn(t, jwk_dtos.JWKSet{Keys: []jwk_dtos.JWK{first}})
+	fetcher := newJWKSFetcher(http.DefaultTransport)
+
+	_, err := fetcher.Fetch(standIn.server.URL, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the client rotates its keys
+	standIn.keys.Store(jwk_dtos.JWKSet{Keys: []jwk_dtos.JWK{second}})
+
+	keys, _ := fetcher.Fetch(standIn.server.URL, false)
+	if keys.Keys[0].Kid != "k1" {
+		t.Fatal("expected the cached key set without refresh")
+	}
+	// refreshes right after a fetch are rate limited
+	keys, _ = fetcher.Fetch(standIn.server.URL, true)
+	if keys.Keys[0].Kid != "k1" || standIn.requests.Load() != 1 {
+		t.Fatal("expected the refresh to be rate limited")
+	}
+
+	fetcher.cache[standIn.server.URL] = cachedJWKS{
+		keys:      fetcher.cache[standIn.server.URL].keys,
+		fetchedAt: time.Now().Add(-time.Minute),
+	}
+	keys, err = fetcher.Fetch(standIn.server.URL, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.Keys[0].Kid != "k2" || standIn.requests.Load() != 2 {
+		t.Fatal("expected the rotated key set to be fetched")
+	}
+}
+
+func TestJWKSFetcherRejectsRedirects(t *testing.T) {
+	_, jwk := newTestSigningKey(t, "k1")
+	target := newJWKSStandIn(t, jwk_dtos.JWKSet{Keys: []jwk_dtos.JWK{jwk}})
+	redirect := httptest.NewServer(http.RedirectHandler(target.server.URL, http.StatusFound))
+	defer redirect.Close()
+
+	_, err := newJWKSFetcher(http.DefaultTransport).Fetch(redirect.URL, false)
+	if err == nil {
+		t.Fatal("expected the redirect to be rejected")
+	}
+	if target.requests.Load() != 0 {
+		t.Fatal("the redirect was followed")
+	}
+}
+
+func TestJWKSFetcherRejectsEmptyKeySets(t *testing.T) {
+	standIn := newJWKSStandIn(t, jwk_dtos.JWKSet{Keys: []jwk_dtos.JWK{}})
+	_, err := newJWKSFetcher(http.DefaultTransport).Fetch(standIn.server.URL, false)
+	if err == nil {
+		t.Fatal("expected an empty key set to be rejected")
+	}
+}
+
+func TestJWKSFetcherBoundsTheCache(t *testing.T) {
+	defer func(size int) { JWKSCacheSize = size }(JWKSCacheSize)
+	JWKSCacheSize = 2
+
+	_, jwk := newTestSigningKey(t, "k1")
+	fetcher := newJWKSFetcher(http.DefaultTransport)
+	var urls []string
+	for range 3 {
+		standIn := newJWKSStandIn(t, jwk_dtos.JWKSet{Keys: []jwk_dtos.JWK{jwk}})
+		urls = append(urls, standIn.server.URL)
+		_, err := fetcher.Fetch(standIn.server.URL, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(fetcher.cache) != 2 {
+		t.Fatalf("expected 2 cached key sets, got %d", len(fetcher.cache))
+	}
+	if _, ok := fetcher.cache[urls[0]]; ok {
+		t.Fatal("expected the oldest key set to be evicted")
+	}
+}
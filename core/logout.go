@@ -0,0 +1,40 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redirects are not followed: the logout token is only sent to the URI the
+// client registered
+var logoutClient = &http.Client{
+	Timeout:   LogoutDeliveryTimeout,
+	Transport: outboundTransport,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Posts a logout token to the back-channel logout URI of a client
+// (OpenID Connect Back-Channel Logout 1.0 section 2.5). The client must
+// answer with a success status.
+func DeliverLogoutToken(logoutURI, logoutToken string) error {
+	form := url.Values{"logout_token": {logoutToken}}
+	request, err := http.NewRequest(http.MethodPost, logoutURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Cache-Control", "no-store")
+	response, err := logoutClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("logout URI answered with status %d", response.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDeliverLogoutToken(t *testing.T) {
+	t.Setenv("REGISTRATION_ALLOW_PRIVATE_URLS", "true")
+
+	tests := []struct {
+		name   string
+		status int
+		valid  bool
+	}{
+		{"ok", http.StatusOK, true},
+		{"no content", http.StatusNoContent, true},
+		{"bad request", http.StatusBadRequest, false},
+		{"server error", http.StatusInternalServerError, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var received string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+					t.Errorf("unexpected request: %s %s", r.Method, r.Header.Get("Content-Type"))
+				}
+				received = r.PostFormValue("logout_token")
+				w.WriteHeader(test.status)
+			}))
+			defer server.Close()
+
+			err := DeliverLogoutToken(server.URL, "logout-token")
+			if received != "logout-token" {
+				t.Fatalf("expected the logout token to be posted, got %q", received)
+			}
+			if test.valid && err != nil {
+				t.Fatalf("expected the delivery to succeed: %v", err)
+			}
+			if !test.valid && err == nil {
+				t.Fatal("expected the delivery to fail")
+			}
+		})
+	}
+}
+
+func TestDeliverLogoutTokenDoesNotFollowRedirects(t *testing.T) {
+	t.Setenv("REGISTRATION_ALLOW_PRIVATE_URLS", "true")
+
+	var hits atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer redirect.Close()
+
+	if err := DeliverLogoutToken(redirect.URL, "logout-token"); err == nil {
+		t.Fatal("expected the redirect to be a failed delivery")
+	}
+	if hits.Load() != 0 {
+		t.Fatal("the redirect was followed")
+	}
+}
+
+func TestDeliverLogoutTokenRefusesPrivateAddresses(t *testing.T) {
+	t.Setenv("REGISTRATION_ALLOW_PRIVATE_URLS", "")
+
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+
+	if err := DeliverLogoutToken(server.URL, "logout-token"); err == nil {
+		t.Fatal("expected the loopback address to be refused")
+	}
+	if hits.Load() != 0 {
+		t.Fatal("the logout token was delivered to a loopback address")
+	}
+}
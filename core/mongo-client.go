@@ -79,7 +79,14 @@ func (mc *MongoClient) FindMany(collectionName string, filter interface{}, opts
 	return cursor, nil
 }
 
-// InsertMany inserts multiple documents into the specified collection
+// FindOneAndUpdate atomically updates a single document and returns it as it was before the update
+func (mc *MongoClient) FindOneAndUpdate(collectionName string, filter interface{}, update interface{}) *mongo.SingleResult {
+	collection := mc.getCollection(collectionName)
+	result := collection.FindOneAndUpdate(context.TODO(), filter, update)
+	return result
+}
+
+// UpdateOne updates a single document in the specified collection
 func (mc *MongoClient) UpdateOne(collectionName string, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
 	collection := mc.getCollection(collectionName)
 	result, err := collection.UpdateOne(context.TODO(), filter, update)
@@ -89,6 +96,16 @@ func (mc *MongoClient) UpdateOne(collectionName string, filter interface{}, upda
 	return result, nil
 }
 
+// UpsertOne updates a single document in the specified collection, or inserts it when none matches
+func (mc *MongoClient) UpsertOne(collectionName string, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	collection := mc.getCollection(collectionName)
+	result, err := collection.UpdateOne(context.TODO(), filter, update, options.UpdateOne().SetUpsert(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert document: %v", err)
+	}
+	return result, nil
+}
+
 // UpdateMany updates multiple documents in the specified collection
 func (mc *MongoClient) UpdateMany(collectionName string, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
 	collection := mc.getCollection(collectionName)
@@ -118,3 +135,31 @@ func (mc *MongoClient) DeleteMany(collectionName string, filter interface{}) (*m
 	}
 	return result, nil
 }
+
+// CreateUniqueIndex creates an index that rejects duplicate values of the given field
+func (mc *MongoClient) CreateUniqueIndex(collectionName string, field string) error {
+	collection := mc.getCollection(collectionName)
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: field, Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := collection.Indexes().CreateOne(context.TODO(), index)
+	if err != nil {
+		return fmt.Errorf("failed to create unique index: %v", err)
+	}
+	return nil
+}
+
+// CreateTTLIndex creates an index that expires documents once the given date field is reached
+func (mc *MongoClient) CreateTTLIndex(collectionName string, field string) error {
+	collection := mc.getCollection(collectionName)
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: field, Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	_, err := collection.Indexes().CreateOne(context.TODO(), index)
+	if err != nil {
+		return fmt.Errorf("failed to create TTL index: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	envmanager_dtos "github.com/keyloom/web-api/dtos/env-manager"
+	token_dtos "github.com/keyloom/web-api/dtos/token"
+)
+
+// Builds the TLS configuration of the server. Client certificates are
+// requested but not verified by the handshake: self-signed certificates are
+// accepted and each client is checked against its registration instead.
+func NewServerTLSConfig(config envmanager_dtos.TLSConfig) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientAuth:   tls.RequestClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// The trusted client CAs are read once, on first use
+var clientCAPool = sync.OnceValues(func() (*x509.CertPool, error) {
+	config, err := (&EnvManager{}).GetTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config.ClientCAFile == "" {
+		return nil, errors.New("no client CA is configured")
+	}
+	bundle, err := os.ReadFile(config.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, errors.New("the client CA file does not contain any certificate")
+	}
+	return pool, nil
+})
+
+// Verifies that the client certificate chains to one of the CAs of
+// TLS_CLIENT_CA_FILE. The first certificate is the client's, the others
+// are intermediates it sent along.
+func VerifyClientCertificate(chain []*x509.Certificate) error {
+	if len(chain) == 0 {
+		return errors.New("no client certificate")
+	}
+	roots, err := clientCAPool()
+	if err != nil {
+		return err
+	}
+	intermediates := x509.NewCertPool()
+	for _, certificate := range chain[1:] {
+		intermediates.AddCert(certificate)
+	}
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
+
+// Reports whether the certificate is within its validity period
+func IsCertificateCurrent(certificate *x509.Certificate) bool {
+	now := time.Now()
+	return !now.Before(certificate.NotBefore) && !now.After(certificate.NotAfter)
+}
+
+// Returns the base64url encoded SHA-256 thumbprint of the DER encoded
+// certificate, the x5t#S256 confirmation method of RFC 8705
+func CertificateThumbprint(certificate *x509.Certificate) string {
+	digest := sha256.Sum256(certificate.Raw)
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+// Checks the certificate binding of a token: tokens without x5t#S256 are
+// not bound, the others may only be used over mutual TLS with the same
+// client certificate (RFC 8705 section 3)
+func CheckCertificateBinding(payload *token_dtos.JWTPayload, chain []*x509.Certificate) bool {
+	if payload.Cnf == nil || payload.Cnf.X5tS256 == "" {
+		return true
+	}
+	if len(chain) == 0 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(CertificateThumbprint(chain[0])), []byte(payload.Cnf.X5tS256)) == 1
+}
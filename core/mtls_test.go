@@ -0,0 +1,100 @@
+package core
+
+import (
+	"crypto"
+	"crypto/x509"
+	"os"
+	"testing"
+	"time"
+
+	token_dtos "github.com/keyloom/web-api/dtos/token"
+	"github.com/keyloom/web-api/internal/testcert"
+)
+
+// Root CA trusted through TLS_CLIENT_CA_FILE and the intermediate CA it
+// issued, which issues client certificates
+var testRootCA, testIntermediateCA *x509.Certificate
+var testRootKey, testIntermediateKey crypto.Signer
+
+func TestMain(m *testing.M) {
+	testRootCA, testRootKey = testcert.MustIssue(testcert.CATemplate("Keyloom Test Root CA"), nil, nil)
+	testIntermediateCA, testIntermediateKey = testcert.MustIssue(testcert.CATemplate("Keyloom Test Intermediate CA"), testRootCA, testRootKey)
+	removeCA := testcert.MustTrustClientCA(testRootCA)
+
+	code := m.Run()
+	removeCA()
+	os.Exit(code)
+}
+
+func TestVerifyClientCertificate(t *testing.T) {
+	leaf, _ := testcert.MustIssue(testcert.ClientTemplate("client", time.Now().Add(time.Hour)), testIntermediateCA, testIntermediateKey)
+	expired, _ := testcert.MustIssue(testcert.ClientTemplate("client", time.Now().Add(-time.Hour)), testIntermediateCA, testIntermediateKey)
+	serverTemplate := testcert.ClientTemplate("server", time.Now().Add(time.Hour))
+	serverTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	serverOnly, _ := testcert.MustIssue(serverTemplate, testIntermediateCA, testIntermediateKey)
+	untrustedCA, untrustedKey := testcert.MustIssue(testcert.CATemplate("Untrusted CA"), nil, nil)
+	untrusted, _ := testcert.MustIssue(testcert.ClientTemplate("client", time.Now().Add(time.Hour)), untrustedCA, untrustedKey)
+	selfSigned, _ := testcert.MustIssue(testcert.ClientTemplate("client", time.Now().Add(time.Hour)), nil, nil)
+
+	tests := []struct {
+		name  string
+		chain []*x509.Certificate
+		valid bool
+	}{
+		{"chain to the trusted root", []*x509.Certificate{leaf, testIntermediateCA}, true},
+		{"missing intermediate", []*x509.Certificate{leaf}, false},
+		{"expired certificate", []*x509.Certificate{expired, testIntermediateCA}, false},
+		{"not for client authentication", []*x509.Certificate{serverOnly, testIntermediateCA}, false},
+		{"untrusted CA", []*x509.Certificate{untrusted, untrustedCA}, false},
+		{"self-signed certificate", []*x509.Certificate{selfSigned}, false},
+		{"no certificate", nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifyClientCertificate(test.chain)
+			if test.valid && err != nil {
+				t.Fatalf("expected the certificate to be accepted: %v", err)
+			}
+			if !test.valid && err == nil {
+				t.Fatal("expected the certificate to be rejected")
+			}
+		})
+	}
+}
+
+func TestIsCertificateCurrent(t *testing.T) {
+	current, _ := testcert.MustIssue(testcert.ClientTemplate("client", time.Now().Add(time.Hour)), testIntermediateCA, testIntermediateKey)
+	expired, _ := testcert.MustIssue(testcert.ClientTemplate("client", time.Now().Add(-time.Hour)), testIntermediateCA, testIntermediateKey)
+	if !IsCertificateCurrent(current) {
+		t.Fatal("expected the certificate to be current")
+	}
+	if IsCertificateCurrent(expired) {
+		t.Fatal("expected the certificate to be expired")
+	}
+}
+
+func TestCheckCertificateBinding(t *testing.T) {
+	bound, _ := testcert.MustIssue(testcert.ClientTemplate("client", time.Now().Add(time.Hour)), testIntermediateCA, testIntermediateKey)
+	other, _ := testcert.MustIssue(testcert.ClientTemplate("client", time.Now().Add(time.Hour)), testIntermediateCA, testIntermediateKey)
+	boundPayload := &token_dtos.JWTPayload{Cnf: &token_dtos.Confirmation{X5tS256: CertificateThumbprint(bound)}}
+
+	tests := []struct {
+		name    string
+		payload *token_dtos.JWTPayload
+		chain   []*x509.Certificate
+		valid   bool
+	}{
+		{"unbound token without certificate", &token_dtos.JWTPayload{}, nil, true},
+		{"DPoP-bound token without certificate", &token_dtos.JWTPayload{Cnf: &token_dtos.Confirmation{Jkt: "thumbprint"}}, nil, true},
+		{"bound token with its certificate", boundPayload, []*x509.Certificate{bound, testIntermediateCA}, true},
+		{"bound token with another certificate", boundPayload, []*x509.Certificate{other, testIntermediateCA}, false},
+		{"bound token without certificate", boundPayload, nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := CheckCertificateBinding(test.payload, test.chain); got != test.valid {
+				t.Fatalf("expected %v, got %v", test.valid, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// Transport of the requests Keyloom sends to URLs registered by clients.
+// Unless private URLs are allowed, connections to loopback, private and
+// link-local addresses are refused once the host name is resolved, so a
+// registered URL cannot reach into the network Keyloom runs in.
+var outboundTransport = &http.Transport{
+	DialContext: (&net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: checkOutboundAddress,
+	}).DialContext,
+	TLSHandshakeTimeout: 5 * time.Second,
+	MaxIdleConns:        100,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+func checkOutboundAddress(network, address string, _ syscall.RawConn) error {
+	if (&EnvManager{}).GetRegistrationConfig().AllowPrivateURLs {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("connections to %s are not allowed", host)
+	}
+	return nil
+}
+
+// Reports whether the address is routable on the internet
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast()
+}
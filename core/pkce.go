@@ -0,0 +1,29 @@
+package core
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+// RFC 7636 section 4.1: 43 to 128 characters from the unreserved set
+var codeVerifierPattern = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// RFC 7636 section 4.2: BASE64URL(SHA256(verifier)) is always 43 characters
+var codeChallengePattern = regexp.MustCompile(`^[A-Za-z0-9\-_]{43}$`)
+
+// Checks that the code challenge is a well formed S256 challenge
+func IsValidCodeChallenge(challenge string) bool {
+	return codeChallengePattern.MatchString(challenge)
+}
+
+// Verifies a PKCE code verifier against the stored S256 code challenge
+func VerifyCodeChallenge(verifier, challenge, method string) bool {
+	if method != CodeChallengeMethodS256 || !codeVerifierPattern.MatchString(verifier) {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+// RFC 7636 appendix B
+const (
+	rfcCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	rfcCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		method    string
+		valid     bool
+	}{
+		{"RFC 7636 example", rfcCodeVerifier, rfcCodeChallenge, CodeChallengeMethodS256, true},
+		{"wrong verifier", "eBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", rfcCodeChallenge, CodeChallengeMethodS256, false},
+		{"plain method", rfcCodeChallenge, rfcCodeChallenge, "plain", false},
+		{"empty method", rfcCodeVerifier, rfcCodeChallenge, "", false},
+		{"verifier too short", rfcCodeVerifier[:42], rfcCodeChallenge, CodeChallengeMethodS256, false},
+		{"verifier too long", strings.Repeat("a", 129), rfcCodeChallenge, CodeChallengeMethodS256, false},
+		{"verifier with reserved characters", rfcCodeVerifier[:42] + "+", rfcCodeChallenge, CodeChallengeMethodS256, false},
+		{"empty verifier", "", rfcCodeChallenge, CodeChallengeMethodS256, false},
+		{"empty challenge", rfcCodeVerifier, "", CodeChallengeMethodS256, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := VerifyCodeChallenge(test.verifier, test.challenge, test.method); got != test.valid {
+				t.Fatalf("expected %v, got %v", test.valid, got)
+			}
+		})
+	}
+}
+
+func TestIsValidCodeChallenge(t *testing.T) {
+	tests := []struct {
+		name      string
+		challenge string
+		valid     bool
+	}{
+		{"RFC 7636 example", rfcCodeChallenge, true},
+		{"too short", rfcCodeChallenge[:42], false},
+		{"too long", rfcCodeChallenge + "A", false},
+		{"padded", rfcCodeChallenge[:42] + "=", false},
+		{"standard base64 alphabet", rfcCodeChallenge[:42] + "+", false},
+		{"empty", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsValidCodeChallenge(test.challenge); got != test.valid {
+				t.Fatalf("expected %v, got %v", test.valid, got)
+			}
+		})
+	}
+}
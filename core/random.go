@@ -0,0 +1,46 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"math/big"
+	"strings"
+)
+
+// Consonants only, so user codes cannot spell words and are easy to type
+var userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// Generates a URL safe random string from the given number of random bytes.
+func GenerateRandomString(byteLength int) (string, error) {
+	bytes := make([]byte, byteLength)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// Generates a user code in the form XXXX-XXXX (RFC 8628 section 6.1)
+func GenerateUserCode() (string, error) {
+	var code strings.Builder
+	for i := 0; i < 8; i++ {
+		if i == 4 {
+			code.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(userCodeAlphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
+
+// Normalizes a user code typed by a user: case and separators are ignored
+func NormalizeUserCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	if len(code) != 8 {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algorithms accepted for signed request objects
+var RequestObjectAlgorithms = ClientAssertionAsymmetricAlgorithms
+
+// Client used to fetch request objects published by clients. Redirects are
+// not followed: objects are only fetched from the request_uri the client
+// registered.
+var requestObjectClient = &http.Client{
+	Timeout:   5 * time.Second,
+	Transport: outboundTransport,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Fetches the request object published at a request_uri (RFC 9101 section 5.2)
+func FetchRequestObject(requestURI string) (string, error) {
+	response, err := requestObjectClient.Get(requestURI)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status fetching the request object: %d", response.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, 64<<10))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// Verifies the request objects of a client
+type RequestObjectVerifier struct {
+	// Returns the keys the object may be signed with, given its kid
+	Keys func(kid string) ([]jwt.VerificationKey, error)
+	// Reject unsigned request objects
+	RequireSigned bool
+	// Issuer of Keyloom, the aud of signed request objects
+	Audience string
+}
+
+// Verifies the request object of the client and returns its claims. Signed
+// objects must be issued by the client for Keyloom, unsigned ones (alg none)
+// are only accepted when signing is not required.
+func (v *RequestObjectVerifier) Verify(object, clientID string) (jwt.MapClaims, error) {
+	unverified, _, err := jwt.NewParser().ParseUnverified(object, jwt.MapClaims{})
+	if err != nil {
+		return nil, errors.New("malformed request object")
+	}
+
+	var token *jwt.Token
+	if unverified.Method == jwt.SigningMethodNone {
+		if v.RequireSigned {
+			return nil, errors.New("the request object must be signed")
+		}
+		token, err = jwt.Parse(object, func(*jwt.Token) (any, error) {
+			return jwt.UnsafeAllowNoneSignatureType, nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodNone.Alg()}))
+	} else {
+		keyfunc := func(token *jwt.Token) (any, error) {
+			kid, _ := token.Header["kid"].(string)
+			keys, err := v.Keys(kid)
+			if err != nil {
+				return nil, err
+			}
+			return jwt.VerificationKeySet{Keys: keys}, nil
+		}
+		token, err = jwt.Parse(object, keyfunc,
+			jwt.WithValidMethods(RequestObjectAlgorithms),
+			jwt.WithIssuer(clientID),
+			jwt.WithAudience(v.Audience),
+		)
+	}
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid request object")
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if objectClientID, _ := claims["client_id"].(string); objectClientID != clientID {
+		return nil, errors.New("the client_id of the request object does not match")
+	}
+	// a request object cannot reference another one
+	if _, ok := claims["request"]; ok {
+		return nil, errors.New("the request object cannot contain request")
+	}
+	if _, ok := claims["request_uri"]; ok {
+		return nil, errors.New("the request object cannot contain request_uri")
+	}
+	return claims, nil
+}
@@ -0,0 +1,13 @@
+package core
+
+import "net/url"
+
+// Reports whether the value is a valid resource indicator: an absolute URI
+// without a fragment (RFC 8707 section 2)
+func IsValidResourceIndicator(resource string) bool {
+	parsed, err := url.Parse(resource)
+	if err != nil {
+		return false
+	}
+	return parsed.IsAbs() && parsed.Fragment == "" && !parsed.ForceQuery
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"slices"
+	"strings"
+)
+
+// Splits a space delimited scope string into its distinct scopes
+func ParseScopes(scope string) []string {
+	scopes := []string{}
+	for _, s := range strings.Fields(scope) {
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// Computes the scopes a token may carry for a user: the requested scopes
+// narrowed down to what the application may request and what the user
+// granted it. OpenID Connect scopes only release the user's own claims and
+// are always allowed. Without requested scopes every allowed API scope is used.
+func EffectiveScopes(requested, applicationScopes, grantScopes []string) []string {
+	allowed := IntersectScopes(applicationScopes, grantScopes)
+	if len(requested) == 0 {
+		return allowed
+	}
+	return IntersectScopes(requested, append(allowed, OpenIDScopes...))
+}
+
+// Returns the scopes of the first list that are also present in the second one
+func IntersectScopes(scopes []string, allowed []string) []string {
+	result := []string{}
+	for _, s := range scopes {
+		if slices.Contains(allowed, s) && !slices.Contains(result, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported token signing algorithms
+var SigningAlgorithmRS256 = "RS256"
+var SigningAlgorithmES256 = "ES256"
+var SigningAlgorithmEdDSA = "EdDSA"
+var SigningAlgorithms = []string{SigningAlgorithmRS256, SigningAlgorithmES256, SigningAlgorithmEdDSA}
+
+// Signing key lifecycle. Pending keys are published but not used yet,
+// retired keys no longer sign but still verify until their tokens expire.
+var SigningKeyStatusPending = "pending"
+var SigningKeyStatusActive = "active"
+var SigningKeyStatusRetired = "retired"
+
+// A key pair used to sign and verify tokens
+type TokenKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// Provides the keys used by the TokenService
+type KeyProvider interface {
+	// Returns the key new tokens are signed with
+	CurrentKey() (*TokenKey, error)
+	// Returns the key with the given ID as long as it may verify tokens.
+	// The private key is not required.
+	KeyByID(kid string) (*TokenKey, error)
+}
+
+// Generates a new private key for the given algorithm
+func GenerateSigningKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case SigningAlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case SigningAlgorithmES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case SigningAlgorithmEdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+}
+
+// Returns the JWT signing method for the given algorithm
+func SigningMethod(algorithm string) (jwt.SigningMethod, error) {
+	switch algorithm {
+	case SigningAlgorithmRS256:
+		return jwt.SigningMethodRS256, nil
+	case SigningAlgorithmES256:
+		return jwt.SigningMethodES256, nil
+	case SigningAlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+}
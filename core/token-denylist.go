@@ -0,0 +1,9 @@
+package core
+
+import token_dtos "github.com/keyloom/web-api/dtos/token"
+
+// Tells the TokenService whether an otherwise valid token has been revoked.
+// Tokens are refused when the denylist cannot be checked.
+type TokenDenylist interface {
+	IsRevoked(payload *token_dtos.JWTPayload) (bool, error)
+}
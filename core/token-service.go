@@ -1,16 +1,26 @@
 package core
 
 import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	token_dtos "github.com/keyloom/web-api/dtos/token"
 )
 
-type TokenService struct{}
+type TokenService struct {
+	Keys     KeyProvider
+	Denylist TokenDenylist
+}
 
 func (s *TokenService) GenerateToken(
-	sub string,
+	claims token_dtos.TokenClaims,
 ) (token_dtos.AccessTokenResponse, error) {
 	config, err := (&EnvManager{}).GetTokenConfig()
 	if err != nil {
@@ -18,51 +28,313 @@ func (s *TokenService) GenerateToken(
 	}
 
 	expirationTime := time.Now().Add(time.Duration(config.TokenDuration) * time.Minute)
+	if claims.NotAfter != 0 && claims.NotAfter < expirationTime.Unix() {
+		expirationTime = time.Unix(claims.NotAfter, 0)
+	}
+
+	// Fall back to the globally configured audience when the caller
+	// does not target any resource server
+	audience := claims.Audience
+	if len(audience) == 0 {
+		audience = []string{config.Audience}
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": sub,
+	jti, err := GenerateRandomString(16)
+	if err != nil {
+		return token_dtos.AccessTokenResponse{}, err
+	}
+
+	mapClaims := jwt.MapClaims{
+		"jti": jti,
+		"sub": claims.Subject,
 		"iss": config.Issuer,
-		"aud": config.Audience,
+		"aud": audience,
+		"iat": time.Now().Unix(),
 		"exp": expirationTime.Unix(),
-	})
+	}
+	if claims.ClientID != "" {
+		mapClaims["client_id"] = claims.ClientID
+	}
+	scope := strings.Join(claims.Scopes, " ")
+	if scope != "" {
+		mapClaims["scope"] = scope
+	}
+	if claims.Act != nil {
+		mapClaims["act"] = claims.Act
+	}
+	if claims.Confirmation != nil {
+		mapClaims["cnf"] = claims.Confirmation
+	}
 
-	signedToken, err := token.SignedString([]byte(config.SecretKey))
+	key, err := s.currentKey()
+	if err != nil {
+		return token_dtos.AccessTokenResponse{}, err
+	}
+	// the explicit type keeps ID and logout tokens, signed with the same
+	// keys, from being accepted as access tokens
+	signedToken, err := s.signWithType(key, mapClaims, AccessTokenJWTType)
 	if err != nil {
 		return token_dtos.AccessTokenResponse{}, err
 	}
 
+	// DPoP-bound tokens must be presented with the DPoP scheme
+	tokenType := "Bearer"
+	if claims.Confirmation != nil && claims.Confirmation.Jkt != "" {
+		tokenType = DPoPTokenType
+	}
+
 	return token_dtos.AccessTokenResponse{
 		AccessToken: signedToken,
-		TokenType:   "Bearer",
+		TokenType:   tokenType,
+		ExpiresIn:   int64(time.Until(expirationTime).Seconds()),
 		ExpiresAt:   expirationTime.Unix(),
+		Scope:       scope,
 	}, nil
 }
 
-func (s *TokenService) ValidateToken(tokenString string) (*token_dtos.JWTPayload, error) {
+// Generates an OpenID Connect ID token for the client
+func (s *TokenService) GenerateIDToken(claims token_dtos.IDTokenClaims) (string, error) {
+	config, err := (&EnvManager{}).GetTokenConfig()
+	if err != nil {
+		return "", err
+	}
+	key, err := s.currentKey()
+	if err != nil {
+		return "", err
+	}
+
+	jti, err := GenerateRandomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	mapClaims := jwt.MapClaims{
+		"jti":       jti,
+		"iss":       config.Issuer,
+		"sub":       claims.Subject,
+		"aud":       claims.ClientID,
+		"azp":       claims.ClientID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(time.Duration(config.TokenDuration) * time.Minute).Unix(),
+		"auth_time": claims.AuthTime,
+	}
+	if claims.Nonce != "" {
+		mapClaims["nonce"] = claims.Nonce
+	}
+	if claims.AccessToken != "" {
+		atHash, err := accessTokenHash(key.Algorithm, claims.AccessToken)
+		if err != nil {
+			return "", err
+		}
+		mapClaims["at_hash"] = atHash
+	}
+	if claims.SessionID != "" {
+		mapClaims["sid"] = claims.SessionID
+	}
+	return s.sign(key, mapClaims)
+}
+
+// Generates a logout token telling the client that the session of the user
+// ended (OpenID Connect Back-Channel Logout 1.0 section 2.4)
+func (s *TokenService) GenerateLogoutToken(claims token_dtos.LogoutTokenClaims) (string, error) {
+	config, err := (&EnvManager{}).GetTokenConfig()
+	if err != nil {
+		return "", err
+	}
+	key, err := s.currentKey()
+	if err != nil {
+		return "", err
+	}
+
+	jti, err := GenerateRandomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	mapClaims := jwt.MapClaims{
+		"jti":    jti,
+		"iss":    config.Issuer,
+		"sub":    claims.Subject,
+		"aud":    claims.ClientID,
+		"iat":    now.Unix(),
+		"exp":    now.Add(LogoutTokenLifetime).Unix(),
+		"events": map[string]interface{}{BackchannelLogoutEvent: map[string]interface{}{}},
+	}
+	if claims.SessionID != "" {
+		mapClaims["sid"] = claims.SessionID
+	}
+	// the explicit type keeps logout tokens from being mistaken for ID tokens
+	return s.signWithType(key, mapClaims, LogoutTokenType)
+}
+
+// Validates an ID token this server issued, passed back by a client as an
+// id_token_hint. Expired ID tokens are accepted: the hint only tells which
+// user and client the request is about.
+func (s *TokenService) ParseIDTokenHint(tokenString string) (*token_dtos.JWTPayload, error) {
 	config, err := (&EnvManager{}).GetTokenConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.SecretKey), nil
-	})
+	token, err := jwt.Parse(tokenString, s.verificationKey, jwt.WithValidMethods(SigningAlgorithms), jwt.WithoutClaimsValidation())
 	if err != nil || !token.Valid {
+		return nil, errors.New("invalid id_token_hint")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid id_token_hint")
+	}
+	audience, err := claims.GetAudience()
+	if err != nil {
+		return nil, errors.New("invalid id_token_hint")
+	}
+
+	var payload token_dtos.JWTPayload
+	payload.Sub, _ = claims["sub"].(string)
+	payload.Iss, _ = claims["iss"].(string)
+	payload.Aud = audience
+	payload.Sid, _ = claims["sid"].(string)
+	// access tokens carry client_id rather than azp
+	payload.ClientID, _ = claims["azp"].(string)
+	if payload.Iss != config.Issuer || payload.Sub == "" || payload.ClientID == "" || !slices.Contains(audience, payload.ClientID) {
+		return nil, errors.New("invalid id_token_hint")
+	}
+	return &payload, nil
+}
+
+// Validates an access token this server issued. ID tokens and logout tokens
+// are signed with the same keys and rejected by their typ header.
+func (s *TokenService) ValidateToken(tokenString string) (*token_dtos.JWTPayload, error) {
+	config, err := (&EnvManager{}).GetTokenConfig()
+	if err != nil {
 		return nil, err
 	}
+
+	token, err := jwt.Parse(tokenString, s.verificationKey, jwt.WithValidMethods(SigningAlgorithms), jwt.WithIssuer(config.Issuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	typ, _ := token.Header["typ"].(string)
+	if !isAccessTokenType(typ) {
+		return nil, errors.New("not an access token")
+	}
 	var payload token_dtos.JWTPayload
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+
+	audience, err := claims.GetAudience()
+	if err != nil {
 		return nil, err
 	}
 
-	payload.Sub = claims["sub"].(string)
-	payload.Iss = claims["iss"].(string)
-	payload.Aud = claims["aud"].(string)
-	payload.Exp = int64(claims["exp"].(float64))
+	payload.Sub, _ = claims["sub"].(string)
+	payload.Iss, _ = claims["iss"].(string)
+	payload.Aud = audience
+	if exp, ok := claims["exp"].(float64); ok {
+		payload.Exp = int64(exp)
+	}
+	if iat, ok := claims["iat"].(float64); ok {
+		payload.Iat = int64(iat)
+	}
+	payload.ClientID, _ = claims["client_id"].(string)
+	payload.Scope, _ = claims["scope"].(string)
+	payload.Jti, _ = claims["jti"].(string)
+	payload.Act, _ = claims["act"].(map[string]interface{})
+	if cnf, ok := claims["cnf"].(map[string]interface{}); ok {
+		payload.Cnf = &token_dtos.Confirmation{}
+		payload.Cnf.X5tS256, _ = cnf["x5t#S256"].(string)
+		payload.Cnf.Jkt, _ = cnf["jkt"].(string)
+	}
 
-	payload.JWTHeader.Alg = token.Header["alg"].(string)
-	payload.JWTHeader.Typ = token.Header["typ"].(string)
+	payload.JWTHeader.Alg, _ = token.Header["alg"].(string)
+	payload.JWTHeader.Typ = typ
+
+	// signature valid tokens may still have been revoked
+	if s.Denylist != nil {
+		revoked, err := s.Denylist.IsRevoked(&payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check token revocation: %w", err)
+		}
+		if revoked {
+			return nil, errors.New("token has been revoked")
+		}
+	}
 
 	return &payload, nil
 }
+
+// Checks the typ header of an access token (RFC 9068 section 4), the media
+// type form with the application/ prefix is accepted too
+func isAccessTokenType(typ string) bool {
+	typ = strings.ToLower(typ)
+	return typ == AccessTokenJWTType || typ == "application/"+AccessTokenJWTType
+}
+
+// Returns the key new tokens are signed with
+func (s *TokenService) currentKey() (*TokenKey, error) {
+	if s.Keys == nil {
+		return nil, errors.New("no signing keys configured")
+	}
+	return s.Keys.CurrentKey()
+}
+
+// Signs the claims with the given key
+func (s *TokenService) sign(key *TokenKey, claims jwt.MapClaims) (string, error) {
+	return s.signWithType(key, claims, "JWT")
+}
+
+// Signs the claims with the given key and typ header
+func (s *TokenService) signWithType(key *TokenKey, claims jwt.MapClaims, typ string) (string, error) {
+	method, err := SigningMethod(key.Algorithm)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.ID
+	token.Header["typ"] = typ
+	return token.SignedString(key.PrivateKey)
+}
+
+// Resolves the public key referenced by the kid header of a token
+func (s *TokenService) verificationKey(token *jwt.Token) (interface{}, error) {
+	if s.Keys == nil {
+		return nil, errors.New("no signing keys configured")
+	}
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, errors.New("missing kid header")
+	}
+	key, err := s.Keys.KeyByID(kid)
+	if err != nil {
+		return nil, err
+	}
+	// the algorithm is bound to the key and cannot be chosen by the token
+	if token.Method.Alg() != key.Algorithm {
+		return nil, errors.New("unexpected signing algorithm")
+	}
+	return key.PublicKey, nil
+}
+
+// Computes the at_hash claim: the left-most half of the access token hash,
+// using the hash function of the signing algorithm (OpenID Connect Core 3.1.3.6)
+func accessTokenHash(algorithm, accessToken string) (string, error) {
+	var sum []byte
+	switch algorithm {
+	case SigningAlgorithmRS256, SigningAlgorithmES256:
+		digest := sha256.Sum256([]byte(accessToken))
+		sum = digest[:]
+	case SigningAlgorithmEdDSA:
+		digest := sha512.Sum512([]byte(accessToken))
+		sum = digest[:]
+	default:
+		return "", errors.New("unsupported signing algorithm")
+	}
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2]), nil
+}
@@ -15,6 +15,50 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Public keys used to verify the tokens issued by Keyloom",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Keys"
+                ],
+                "summary": "JSON Web Key Set",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/jwk_dtos.JWKSet"
+                        }
+                    }
+                }
+            }
+        },
+        "/.well-known/openid-configuration": {
+            "get": {
+                "description": "OpenID Provider metadata derived from the configured issuer and the registered routes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OpenID Connect"
+                ],
+                "summary": "OpenID Connect discovery document",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/oidc_dtos.OpenIDConfiguration"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
         "/applications/": {
             "get": {
                 "description": "Retrieve a paginated list of all applications",
@@ -57,6 +101,14 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {}
@@ -65,10 +117,15 @@ const docTemplate = `{
                         "description": "Internal Server Error",
                         "schema": {}
                     }
-                }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
             },
             "post": {
-                "description": "Create a new application with the provided name, description, and audience ID",
+                "description": "Create a new application with the provided name, description and type. The grant types and token endpoint authentication method must be allowed for the type.",
                 "consumes": [
                     "application/json"
                 ],
@@ -101,11 +158,24 @@ const docTemplate = `{
                         "description": "Bad Request",
                         "schema": {}
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
                     }
-                }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
             }
         },
         "/applications/{id}": {
@@ -137,14 +207,27 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.Application"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {}
                     }
-                }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
             },
             "put": {
-                "description": "Update an existing application's name and description",
+                "description": "Update an existing application's name, description, type and keys. Omitted grant types and authentication method are kept unless the type changes, omitted keys, request URIs, logout URIs and flags are kept.",
                 "consumes": [
                     "application/json"
                 ],
@@ -169,7 +252,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/application_dtos.CreateApplicationDTO"
+                            "$ref": "#/definitions/application_dtos.UpdateApplicationDTO"
                         }
                     }
                 ],
@@ -184,6 +267,14 @@ const docTemplate = `{
                         "description": "Bad Request",
                         "schema": {}
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {}
@@ -192,27 +283,36 @@ const docTemplate = `{
                         "description": "Internal Server Error",
                         "schema": {}
                     }
-                }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
             }
         },
-        "/resource-servers/": {
+        "/applications/{id}/logout-deliveries": {
             "get": {
-                "description": "Retrieve a paginated list of resource servers",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Retrieve the logout notifications sent to the back-channel logout URI of the application, newest first, with their delivery status",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "ResourceServers"
+                    "Applications"
                 ],
-                "summary": "Get all resource servers with pagination",
+                "summary": "Get the back-channel logout deliveries of an application",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Application ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "integer",
                         "default": 10,
-                        "description": "Number of resource servers to return",
+                        "description": "Number of deliveries to return",
                         "name": "limit",
                         "in": "query"
                     },
@@ -230,18 +330,33 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/entities.ResourceServer"
+                                "$ref": "#/definitions/entities.LogoutDelivery"
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {}
                     }
-                }
-            },
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/applications/{id}/secrets": {
             "post": {
-                "description": "Create a new resource server with the provided display name and description",
+                "description": "Generate a new client secret. The plaintext is only returned in this response, existing secrets stay valid so they can be rotated without downtime.",
                 "consumes": [
                     "application/json"
                 ],
@@ -249,17 +364,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "ResourceServers"
+                    "Applications"
                 ],
-                "summary": "Create a new resource server",
+                "summary": "Create a client secret",
                 "parameters": [
                     {
-                        "description": "Resource server creation data",
+                        "type": "string",
+                        "description": "Application ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Client secret data",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/resource_server_dtos.CreateResourceServerDTO"
+                            "$ref": "#/definitions/application_dtos.CreateClientSecretDTO"
                         }
                     }
                 ],
@@ -267,94 +389,70 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/entities.ResourceServer"
+                            "$ref": "#/definitions/application_dtos.ClientSecretResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
                     }
-                }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
             }
         },
-        "/resource-servers/{id}": {
-            "get": {
-                "description": "Retrieve a resource server by its ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+        "/applications/{id}/secrets/{secret_id}": {
+            "delete": {
+                "description": "Revoke a client secret, clients using it can no longer authenticate",
                 "tags": [
-                    "ResourceServers"
+                    "Applications"
                 ],
-                "summary": "Get resource server by ID",
+                "summary": "Delete a client secret",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Resource Server ID",
+                        "description": "Application ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/entities.ResourceServer"
-                        }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {}
-                    }
-                }
-            },
-            "put": {
-                "description": "Update an existing resource server's display name and description",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "ResourceServers"
-                ],
-                "summary": "Update an existing resource server",
-                "parameters": [
                     {
                         "type": "string",
-                        "description": "Resource Server ID",
-                        "name": "id",
+                        "description": "Client secret ID",
+                        "name": "secret_id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Resource Server update data",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/resource_server_dtos.UpdateResourceServerDTO"
-                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/entities.ResourceServer"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {}
                     },
                     "404": {
@@ -365,272 +463,3228 @@ const docTemplate = `{
                         "description": "Internal Server Error",
                         "schema": {}
                     }
-                }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
             }
         },
-        "/token/": {
-            "post": {
-                "description": "Dispatch tokens based on the provided grant type",
-                "consumes": [
-                    "application/x-www-form-urlencoded"
-                ],
+        "/authorize": {
+            "get": {
+                "description": "Start an authorization code flow. Users signed in to a browser session continue without signing in again, others get the sign in page.",
                 "produces": [
-                    "application/json"
+                    "text/html"
                 ],
                 "tags": [
-                    "Tokens"
+                    "Authorization"
                 ],
-                "summary": "Token dispatch endpoint",
+                "summary": "Authorization endpoint",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Grant type",
-                        "name": "grant_type",
-                        "in": "formData",
+                        "description": "Must be code",
+                        "name": "response_type",
+                        "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Username for password grant",
-                        "name": "username",
-                        "in": "formData"
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "query",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Password for password grant",
-                        "name": "password",
-                        "in": "formData"
+                        "description": "Registered redirect URI",
+                        "name": "redirect_uri",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Client ID for client credentials grant",
-                        "name": "client_id",
-                        "in": "formData"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/token_dtos.AccessTokenResponse"
-                        }
+                        "description": "Space delimited scopes",
+                        "name": "scope",
+                        "in": "query"
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {}
+                    {
+                        "type": "string",
+                        "description": "Opaque value returned to the client",
+                        "name": "state",
+                        "in": "query"
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {}
+                    {
+                        "type": "string",
+                        "description": "PKCE code challenge",
+                        "name": "code_challenge",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code challenge method, must be S256",
+                        "name": "code_challenge_method",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "OpenID Connect nonce, returned in the ID token",
+                        "name": "nonce",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space delimited prompts: none fails unless the user is signed in and consented, login forces a new sign in, consent forces the consent page",
+                        "name": "prompt",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum age in seconds of the sign in of the user, older sessions require a new sign in",
+                        "name": "max_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Resource indicators of the resource servers the tokens are meant for",
+                        "name": "resource",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request object, signed with a registered key of the client unless it allows unsigned ones, its parameters take precedence",
+                        "name": "request",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "request_uri returned by /par, whose parameters replace all others but client_id, or registered URL of a request object",
+                        "name": "request_uri",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sign in or consent page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirect to the client with an error, login_required or consent_required with prompt=none",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Error page",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/token/me/validate": {
-            "get": {
-                "security": [
+        "/authorize/consent": {
+            "post": {
+                "description": "Record the decision of the user signed in to the browser session as a grant and redirect back to the client",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Authorization"
+                ],
+                "summary": "Consent to a pending authorization request",
+                "parameters": [
                     {
-                        "ApiKeyAuth": []
+                        "type": "string",
+                        "description": "Pending authorization request ID",
+                        "name": "request_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Anti-CSRF value of the consent page",
+                        "name": "csrf_token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "approve or deny",
+                        "name": "action",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
-                "description": "Validate the provided JWT token",
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the client with the authorization code or an error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Error page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/authorize/login": {
+            "post": {
+                "description": "Authenticate the user and start their browser session, then ask for consent or redirect back to the client with an authorization code",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
                 "produces": [
-                    "application/json"
+                    "text/html"
                 ],
                 "tags": [
-                    "Tokens"
+                    "Authorization"
+                ],
+                "summary": "Sign in for a pending authorization request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pending authorization request ID",
+                        "name": "request_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Anti-CSRF value of the sign in page",
+                        "name": "csrf_token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User email",
+                        "name": "username",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User password",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    }
                 ],
-                "summary": "Validate token endpoint",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Consent page",
                         "schema": {
-                            "$ref": "#/definitions/token_dtos.JWTPayload"
+                            "type": "string"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirect to the client with the authorization code",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
-                        "schema": {}
+                        "description": "Error page",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
                     "401": {
-                        "description": "Unauthorized",
-                        "schema": {}
+                        "description": "Sign in page with an error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Sign in page, the user is disabled",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/users/": {
+        "/device": {
+            "get": {
+                "description": "Render the page where the user enters the code of a device and approves it",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Device"
+                ],
+                "summary": "Device verification page",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User code displayed on the device",
+                        "name": "user_code",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Verification page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Create a new user with the provided email and password",
+                "description": "Authenticate the user and record their decision for the device",
                 "consumes": [
-                    "application/json"
+                    "application/x-www-form-urlencoded"
                 ],
                 "produces": [
-                    "application/json"
+                    "text/html"
                 ],
                 "tags": [
-                    "Users"
+                    "Device"
                 ],
-                "summary": "Create a new user",
+                "summary": "Approve or deny a device",
                 "parameters": [
                     {
-                        "description": "User creation data",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "User code displayed on the device",
+                        "name": "user_code",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User email",
+                        "name": "username",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User password",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "approve or deny",
+                        "name": "action",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Result page",
                         "schema": {
-                            "$ref": "#/definitions/user_dtos.CreateUserDTO"
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Verification page with an error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Verification page with an error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Verification page, the user is disabled",
+                        "schema": {
+                            "type": "string"
                         }
                     }
+                }
+            }
+        },
+        "/device/authorize": {
+            "post": {
+                "description": "Issue a device code and a user code for input constrained devices (RFC 8628)",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Device"
+                ],
+                "summary": "Device authorization endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client secret of confidential clients",
+                        "name": "client_secret",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer for private_key_jwt and client_secret_jwt clients",
+                        "name": "client_assertion_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT authenticating the client",
+                        "name": "client_assertion",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space delimited scopes",
+                        "name": "scope",
+                        "in": "formData"
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entities.User"
+                            "$ref": "#/definitions/device_dtos.DeviceAuthorizationResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
                     }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "application_dtos.CreateApplicationDTO": {
-            "type": "object",
-            "required": [
-                "name"
-            ],
-            "properties": {
-                "description": {
+                }
+            }
+        },
+        "/grants/": {
+            "get": {
+                "description": "Retrieve a paginated list of grants, newest first, with their user and application",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Grants"
+                ],
+                "summary": "Get all grants with pagination",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of grants to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only the grants of this user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only the grants to this application",
+                        "name": "application_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entities.Grant"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Allow an application to use scopes on behalf of a user, as if the user consented. The scopes must be allowed for the application, a user has at most one grant per application.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Grants"
+                ],
+                "summary": "Create a new grant",
+                "parameters": [
+                    {
+                        "description": "Grant creation data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/grant_dtos.CreateGrantDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Grant"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/grants/{id}": {
+            "get": {
+                "description": "Retrieve a grant by its ID, with its user and application",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Grants"
+                ],
+                "summary": "Get grant by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Grant ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Grant"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Replace the scopes of a grant. Scopes that are removed can no longer be obtained through refresh tokens, access tokens already issued keep them until they expire.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Grants"
+                ],
+                "summary": "Update an existing grant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Grant ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Grant update data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/grant_dtos.UpdateGrantDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Grant"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a grant. The refresh tokens and access tokens issued to the application on behalf of the user are revoked, the user is asked to consent again on their next sign in.",
+                "tags": [
+                    "Grants"
+                ],
+                "summary": "Revoke a grant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Grant ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/logout": {
+            "get": {
+                "description": "Sign the user out of their browser session (OpenID Connect RP-Initiated Logout). The user is asked to confirm unless a valid id_token_hint of the signed in user is sent. The clients of the session are notified through their back-channel logout URI and, from the signed out page, their front-channel logout URI. The user is then redirected to the post logout redirect URI when one is given.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "OpenID Connect"
+                ],
+                "summary": "End session endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID token previously issued to the client, expired ones are accepted",
+                        "name": "id_token_hint",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID, required with post_logout_redirect_uri when no id_token_hint is sent",
+                        "name": "client_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Registered post logout redirect URI of the client",
+                        "name": "post_logout_redirect_uri",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque value returned to the client",
+                        "name": "state",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Confirmation or signed out page, which loads the front-channel logout URIs before redirecting",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirect to the post logout redirect URI",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Error page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Sign the user out of their browser session (OpenID Connect RP-Initiated Logout). The user is asked to confirm unless a valid id_token_hint of the signed in user is sent. The clients of the session are notified through their back-channel logout URI and, from the signed out page, their front-channel logout URI. The user is then redirected to the post logout redirect URI when one is given.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "OpenID Connect"
+                ],
+                "summary": "End session endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID token previously issued to the client, expired ones are accepted",
+                        "name": "id_token_hint",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID, required with post_logout_redirect_uri when no id_token_hint is sent",
+                        "name": "client_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Registered post logout redirect URI of the client",
+                        "name": "post_logout_redirect_uri",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque value returned to the client",
+                        "name": "state",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Confirmation or signed out page, which loads the front-channel logout URIs before redirecting",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirect to the post logout redirect URI",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Error page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/par": {
+            "post": {
+                "description": "Push the parameters of an authorization request from the back channel (RFC 9126). The returned request_uri is passed to /authorize along with the client_id.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authorization"
+                ],
+                "summary": "Pushed authorization request endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must be code",
+                        "name": "response_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID when not using HTTP Basic authentication",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client secret of confidential clients",
+                        "name": "client_secret",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer for private_key_jwt and client_secret_jwt clients",
+                        "name": "client_assertion_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT authenticating the client",
+                        "name": "client_assertion",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Registered redirect URI",
+                        "name": "redirect_uri",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space delimited scopes",
+                        "name": "scope",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque value returned to the client",
+                        "name": "state",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code challenge",
+                        "name": "code_challenge",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code challenge method, must be S256",
+                        "name": "code_challenge_method",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "OpenID Connect nonce, returned in the ID token",
+                        "name": "nonce",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space delimited prompts: none, login or consent",
+                        "name": "prompt",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum age in seconds of the sign in of the user",
+                        "name": "max_age",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Resource indicators of the resource servers the tokens are meant for",
+                        "name": "resource",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request object, its parameters take precedence",
+                        "name": "request",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/authorize_dtos.PushedAuthorizationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "Register an OAuth client (RFC 7591). An initial access token is required when REGISTRATION_INITIAL_ACCESS_TOKEN is configured, the password grant type cannot be registered. The jwks_uri, request_uris and backchannel_logout_uri must use https and public addresses unless REGISTRATION_ALLOW_PRIVATE_URLS is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Registration"
+                ],
+                "summary": "Dynamic client registration endpoint",
+                "parameters": [
+                    {
+                        "description": "Client metadata",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/registration_dtos.ClientMetadata"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/registration_dtos.ClientInformationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/register/{client_id}": {
+            "get": {
+                "description": "Read the metadata of a dynamically registered client using its registration access token (RFC 7592)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Registration"
+                ],
+                "summary": "Read a client registration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/registration_dtos.ClientInformationResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Replace the metadata of a dynamically registered client using its registration access token (RFC 7592)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Registration"
+                ],
+                "summary": "Update a client registration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Complete client metadata, omitted fields are reset",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/registration_dtos.ClientUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/registration_dtos.ClientInformationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Deprovision a dynamically registered client using its registration access token (RFC 7592)",
+                "tags": [
+                    "Registration"
+                ],
+                "summary": "Delete a client registration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/resource-servers/": {
+            "get": {
+                "description": "Retrieve a paginated list of resource servers",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ResourceServers"
+                ],
+                "summary": "Get all resource servers with pagination",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of resource servers to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entities.ResourceServer"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create a new resource server with the provided display name, description and audience identifier",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ResourceServers"
+                ],
+                "summary": "Create a new resource server",
+                "parameters": [
+                    {
+                        "description": "Resource server creation data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/resource_server_dtos.CreateResourceServerDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/entities.ResourceServer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/resource-servers/{id}": {
+            "get": {
+                "description": "Retrieve a resource server by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ResourceServers"
+                ],
+                "summary": "Get resource server by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Resource Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entities.ResourceServer"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update an existing resource server's display name, description and scope definitions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ResourceServers"
+                ],
+                "summary": "Update an existing resource server",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Resource Server ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Resource Server update data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/resource_server_dtos.UpdateResourceServerDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entities.ResourceServer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/token/": {
+            "post": {
+                "description": "Dispatch tokens based on the provided grant type",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tokens"
+                ],
+                "summary": "Token dispatch endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Grant type",
+                        "name": "grant_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "DPoP proof binding the issued tokens to the client key, with a nonce from the DPoP-Nonce header",
+                        "name": "DPoP",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username for password grant",
+                        "name": "username",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password for password grant",
+                        "name": "password",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID for client credentials grant",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client secret for client credentials grant",
+                        "name": "client_secret",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer for private_key_jwt and client_secret_jwt clients",
+                        "name": "client_assertion_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT authenticating the client",
+                        "name": "client_assertion",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code for authorization code grant",
+                        "name": "code",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Redirect URI used in the authorization request",
+                        "name": "redirect_uri",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code verifier for authorization code grant",
+                        "name": "code_verifier",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Refresh token for refresh token grant",
+                        "name": "refresh_token",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space delimited scopes, for refresh token and token exchange grants a subset of the original scopes",
+                        "name": "scope",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device code for device code grant",
+                        "name": "device_code",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Resource indicators of the resource servers the token is meant for",
+                        "name": "resource",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Keyloom access token issued for a resource server linked to the client, for token exchange grant",
+                        "name": "subject_token",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Type of the subject token for token exchange grant",
+                        "name": "subject_token_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Keyloom access token of the acting party for token exchange grant",
+                        "name": "actor_token",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Type of the actor token for token exchange grant",
+                        "name": "actor_token_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resource server name or identifier the exchanged token is meant for",
+                        "name": "audience",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Must be an access token type when set",
+                        "name": "requested_token_type",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/token_dtos.AccessTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/token/introspect": {
+            "post": {
+                "description": "Report the state of a token to an authenticated client (RFC 7662)",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tokens"
+                ],
+                "summary": "Token introspection endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access or refresh token to introspect",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "access_token or refresh_token",
+                        "name": "token_type_hint",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID when not using HTTP Basic authentication",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client secret when not using HTTP Basic authentication",
+                        "name": "client_secret",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer for private_key_jwt and client_secret_jwt clients",
+                        "name": "client_assertion_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT authenticating the client",
+                        "name": "client_assertion",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/token_dtos.IntrospectionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/token/me/validate": {
+            "get": {
+                "description": "Validate the provided JWT token",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tokens"
+                ],
+                "summary": "Validate token endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/token_dtos.JWTPayload"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/token/revoke": {
+            "post": {
+                "description": "Revoke an access or refresh token issued to the calling client (RFC 7009)",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tokens"
+                ],
+                "summary": "Token revocation endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access or refresh token to revoke",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "access_token or refresh_token",
+                        "name": "token_type_hint",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID when not using HTTP Basic authentication",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client secret of confidential clients",
+                        "name": "client_secret",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer for private_key_jwt and client_secret_jwt clients",
+                        "name": "client_assertion_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT authenticating the client",
+                        "name": "client_assertion",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {}
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/userinfo": {
+            "get": {
+                "description": "Claims about the authenticated user, filtered by the profile and email scopes of the access token. DPoP-bound tokens require the DPoP scheme and a proof.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OpenID Connect"
+                ],
+                "summary": "OpenID Connect UserInfo endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "DPoP proof for DPoP-bound access tokens",
+                        "name": "DPoP",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/oidc_dtos.UserInfoResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/users/": {
+            "get": {
+                "description": "Retrieve a paginated list of users, oldest first, optionally filtered by email, status and creation date",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get all users with pagination",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of users to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Part of the email, case insensitive",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "active or disabled",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only users created at or after this Unix timestamp",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only users created before this Unix timestamp",
+                        "name": "created_before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entities.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create a new user with the provided email and password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User creation data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_dtos.CreateUserDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entities.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "description": "Retrieve a user by their ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entities.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Update the email, password, status or names of a user, omitted fields are kept. A new email must be verified again. Changing the password or disabling the user signs them out everywhere and revokes their tokens.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Update an existing user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User update data",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_dtos.UpdateUserDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entities.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a user along with their grants. The user is signed out everywhere and their tokens are revoked.",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Delete a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        }
+    },
+    "definitions": {
+        "application_dtos.ClientSecretResponse": {
+            "type": "object",
+            "properties": {
+                "client_secret": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "integer"
+                },
+                "expire_at": {
+                    "type": "integer"
+                },
+                "hint": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "application_dtos.CreateApplicationDTO": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "backchannel_logout_session_required": {
+                    "type": "boolean"
+                },
+                "backchannel_logout_uri": {
+                    "description": "Notified when a session the application took part in ends",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "dpop_bound_access_tokens": {
+                    "description": "Require DPoP proofs at the token endpoint",
+                    "type": "boolean"
+                },
+                "frontchannel_logout_session_required": {
+                    "type": "boolean"
+                },
+                "frontchannel_logout_uri": {
+                    "type": "string"
+                },
+                "grant_types": {
+                    "description": "Default to those of the application type",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "jwks": {
+                    "description": "Keys of private_key_jwt applications, inline or by URL",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/jwk_dtos.JWKSet"
+                        }
+                    ]
+                },
+                "jwks_uri": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "post_logout_redirect_uris": {
+                    "description": "URIs users may be sent back to after signing out",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "request_uris": {
+                    "description": "URLs the application publishes request objects at",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "require_pushed_authorization_requests": {
+                    "description": "Only accept authorization requests pushed to /par",
+                    "type": "boolean"
+                },
+                "require_signed_request_object": {
+                    "description": "Reject authorization requests without a signed request object",
+                    "type": "boolean"
+                },
+                "tls_client_auth_subject_dn": {
+                    "description": "Subject DN of the certificate of tls_client_auth applications",
+                    "type": "string"
+                },
+                "tls_client_certificate_bound_access_tokens": {
+                    "description": "Bind access tokens to the client certificate",
+                    "type": "boolean"
+                },
+                "token_endpoint_auth_method": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "spa, native, web or m2m, defaults to spa on creation",
+                    "type": "string",
+                    "enum": [
+                        "spa",
+                        "native",
+                        "web",
+                        "m2m"
+                    ]
+                }
+            }
+        },
+        "application_dtos.CreateClientSecretDTO": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "expire_at": {
+                    "description": "Unix timestamp after which the secret is rejected, 0 for no expiry",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "application_dtos.UpdateApplicationDTO": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "backchannel_logout_session_required": {
+                    "type": "boolean"
+                },
+                "backchannel_logout_uri": {
+                    "description": "Notified when a session the application took part in ends",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "dpop_bound_access_tokens": {
+                    "description": "Require DPoP proofs at the token endpoint",
+                    "type": "boolean"
+                },
+                "frontchannel_logout_session_required": {
+                    "type": "boolean"
+                },
+                "frontchannel_logout_uri": {
+                    "type": "string"
+                },
+                "grant_types": {
+                    "description": "Default to those of the application type when it changes",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "jwks": {
+                    "description": "Keys of private_key_jwt applications, inline or by URL",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/jwk_dtos.JWKSet"
+                        }
+                    ]
+                },
+                "jwks_uri": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "post_logout_redirect_uris": {
+                    "description": "URIs users may be sent back to after signing out",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "request_uris": {
+                    "description": "URLs the application publishes request objects at",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "require_pushed_authorization_requests": {
+                    "description": "Only accept authorization requests pushed to /par",
+                    "type": "boolean"
+                },
+                "require_signed_request_object": {
+                    "description": "Reject authorization requests without a signed request object",
+                    "type": "boolean"
+                },
+                "tls_client_auth_subject_dn": {
+                    "description": "Subject DN of the certificate of tls_client_auth applications",
+                    "type": "string"
+                },
+                "tls_client_certificate_bound_access_tokens": {
+                    "description": "Bind access tokens to the client certificate",
+                    "type": "boolean"
+                },
+                "token_endpoint_auth_method": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "spa, native, web or m2m, kept when omitted",
+                    "type": "string",
+                    "enum": [
+                        "spa",
+                        "native",
+                        "web",
+                        "m2m"
+                    ]
+                }
+            }
+        },
+        "authorize_dtos.PushedAuthorizationResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "type": "integer"
+                },
+                "request_uri": {
+                    "type": "string"
+                }
+            }
+        },
+        "device_dtos.DeviceAuthorizationResponse": {
+            "type": "object",
+            "properties": {
+                "device_code": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "type": "integer"
+                },
+                "interval": {
+                    "type": "integer"
+                },
+                "user_code": {
+                    "type": "string"
+                },
+                "verification_uri": {
+                    "type": "string"
+                },
+                "verification_uri_complete": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Application": {
+            "type": "object",
+            "properties": {
+                "backchannel_logout_session_required": {
+                    "type": "boolean"
+                },
+                "backchannel_logout_uri": {
+                    "description": "Notified when a session the client took part in ends, from the server\n(OpenID Connect Back-Channel Logout) or from the browser of the user\n(OpenID Connect Front-Channel Logout). The session required flags add\nthe issuer and session ID.",
+                    "type": "string"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "client_secrets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.ClientSecret"
+                    }
+                },
+                "client_uri": {
+                    "type": "string"
+                },
+                "contacts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "dpop_bound_access_tokens": {
+                    "description": "Require DPoP proofs at the token endpoint (RFC 9449 section 5.2)",
+                    "type": "boolean"
+                },
+                "frontchannel_logout_session_required": {
+                    "type": "boolean"
+                },
+                "frontchannel_logout_uri": {
+                    "type": "string"
+                },
+                "grant_types": {
+                    "description": "Client metadata (RFC 7591 section 2)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "jwks": {
+                    "description": "Keys of private_key_jwt clients, registered inline or published at a URL",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/jwk_dtos.JWKSet"
+                        }
+                    ]
+                },
+                "jwks_uri": {
+                    "type": "string"
+                },
+                "logo_uri": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "post_logout_redirect_uris": {
+                    "description": "URIs the user may be sent back to after signing out",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "redirect_uris": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "request_uris": {
+                    "description": "URLs the client publishes request objects at (RFC 9101 section 5.2)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "require_pushed_authorization_requests": {
+                    "description": "Only accept authorization requests pushed to /par (RFC 9126 section 6)",
+                    "type": "boolean"
+                },
+                "require_signed_request_object": {
+                    "description": "Reject authorization requests without a signed request object",
+                    "type": "boolean"
+                },
+                "resource_servers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.ResourceServer"
+                    }
+                },
+                "response_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tls_client_auth_subject_dn": {
+                    "description": "Subject DN of the certificate of tls_client_auth clients (RFC 8705)",
+                    "type": "string"
+                },
+                "tls_client_certificate_bound_access_tokens": {
+                    "description": "Bind the access tokens of the client to its certificate",
+                    "type": "boolean"
+                },
+                "token_endpoint_auth_method": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "One of core.ApplicationTypes, bounds the grant types and authentication method",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entities.ClientSecret": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "integer"
+                },
+                "expire_at": {
+                    "type": "integer"
+                },
+                "hint": {
+                    "description": "Last characters of the plaintext, to tell secrets apart",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Grant": {
+            "type": "object",
+            "properties": {
+                "application": {
+                    "$ref": "#/definitions/entities.Application"
+                },
+                "application_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "integer"
+                },
+                "user": {
+                    "$ref": "#/definitions/entities.User"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.LogoutDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "integer"
+                },
+                "expire_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "One of pending, delivered or failed",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "integer"
+                },
+                "uri": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.ResourceServer": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "identifier": {
+                    "description": "Unique audience of the tokens issued for this resource server (RFC 8707)",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.ResourceServerScope"
+                    }
+                },
+                "updated_at": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entities.ResourceServerScope": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "integer"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "email_verified": {
+                    "type": "boolean"
+                },
+                "family_name": {
+                    "type": "string"
+                },
+                "given_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "active or disabled, users created before statuses existed have none",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "integer"
+                }
+            }
+        },
+        "grant_dtos.CreateGrantDTO": {
+            "type": "object",
+            "required": [
+                "application_id",
+                "scopes",
+                "user_id"
+            ],
+            "properties": {
+                "application_id": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "grant_dtos.UpdateGrantDTO": {
+            "type": "object",
+            "required": [
+                "scopes"
+            ],
+            "properties": {
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "jwk_dtos.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "description": "EC and OKP public key parameters",
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "description": "RSA public key parameters",
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                },
+                "x5c": {
+                    "description": "Certificate chain of the key, base64 DER encoded",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "y": {
+                    "type": "string"
+                }
+            }
+        },
+        "jwk_dtos.JWKSet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/jwk_dtos.JWK"
+                    }
+                }
+            }
+        },
+        "oidc_dtos.OpenIDConfiguration": {
+            "type": "object",
+            "properties": {
+                "authorization_endpoint": {
+                    "type": "string"
+                },
+                "backchannel_logout_session_supported": {
+                    "type": "boolean"
+                },
+                "backchannel_logout_supported": {
+                    "description": "Logout notifications (Back-Channel and Front-Channel Logout 1.0 section 2.1)",
+                    "type": "boolean"
+                },
+                "claims_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "code_challenge_methods_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "device_authorization_endpoint": {
+                    "type": "string"
+                },
+                "dpop_signing_alg_values_supported": {
+                    "description": "Algorithms of DPoP proofs (RFC 9449 section 5.1)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "end_session_endpoint": {
+                    "type": "string"
+                },
+                "frontchannel_logout_session_supported": {
+                    "type": "boolean"
+                },
+                "frontchannel_logout_supported": {
+                    "type": "boolean"
+                },
+                "grant_types_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id_token_signing_alg_values_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "introspection_endpoint": {
+                    "type": "string"
+                },
+                "issuer": {
+                    "type": "string"
+                },
+                "jwks_uri": {
+                    "type": "string"
+                },
+                "pushed_authorization_request_endpoint": {
+                    "type": "string"
+                },
+                "registration_endpoint": {
+                    "type": "string"
+                },
+                "request_object_signing_alg_values_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "request_parameter_supported": {
+                    "description": "Request objects (RFC 9101 section 10.1)",
+                    "type": "boolean"
+                },
+                "request_uri_parameter_supported": {
+                    "type": "boolean"
+                },
+                "require_pushed_authorization_requests": {
+                    "description": "Applications can require PAR individually, it is never required globally",
+                    "type": "boolean"
+                },
+                "require_request_uri_registration": {
+                    "type": "boolean"
+                },
+                "response_types_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "revocation_endpoint": {
+                    "type": "string"
+                },
+                "scopes_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "subject_types_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tls_client_certificate_bound_access_tokens": {
+                    "description": "Whether tokens can be bound to client certificates (RFC 8705 section 3.3)",
+                    "type": "boolean"
+                },
+                "token_endpoint": {
+                    "type": "string"
+                },
+                "token_endpoint_auth_methods_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token_endpoint_auth_signing_alg_values_supported": {
+                    "description": "Algorithms of client assertions",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "userinfo_endpoint": {
+                    "type": "string"
+                }
+            }
+        },
+        "oidc_dtos.UserInfoResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "email_verified": {
+                    "type": "boolean"
+                },
+                "family_name": {
+                    "type": "string"
+                },
+                "given_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sub": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "integer"
+                }
+            }
+        },
+        "registration_dtos.ClientInformationResponse": {
+            "type": "object",
+            "properties": {
+                "application_type": {
+                    "description": "web or native (OpenID Connect Dynamic Client Registration), defaults to web",
+                    "type": "string"
+                },
+                "backchannel_logout_session_required": {
+                    "type": "boolean"
+                },
+                "backchannel_logout_uri": {
+                    "description": "OpenID Connect Back-Channel and Front-Channel Logout 1.0 section 2.2",
+                    "type": "string"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "client_id_issued_at": {
+                    "type": "integer"
+                },
+                "client_name": {
+                    "type": "string"
+                },
+                "client_secret": {
+                    "type": "string"
+                },
+                "client_secret_expires_at": {
+                    "description": "Zero when the secret does not expire, only set along with a secret",
+                    "type": "integer"
+                },
+                "client_uri": {
+                    "type": "string"
+                },
+                "contacts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "dpop_bound_access_tokens": {
+                    "description": "Always use DPoP at the token endpoint (RFC 9449 section 5.2)",
+                    "type": "boolean"
+                },
+                "frontchannel_logout_session_required": {
+                    "type": "boolean"
+                },
+                "frontchannel_logout_uri": {
+                    "type": "string"
+                },
+                "grant_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "jwks": {
+                    "description": "Keys of private_key_jwt clients, inline or by URL but not both",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/jwk_dtos.JWKSet"
+                        }
+                    ]
+                },
+                "jwks_uri": {
+                    "type": "string"
+                },
+                "logo_uri": {
+                    "type": "string"
+                },
+                "post_logout_redirect_uris": {
+                    "description": "OpenID Connect RP-Initiated Logout 1.0 section 3.1",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "redirect_uris": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "registration_access_token": {
+                    "type": "string"
+                },
+                "registration_client_uri": {
+                    "type": "string"
+                },
+                "request_uris": {
+                    "description": "Request object metadata (RFC 9101 section 10.2)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "require_pushed_authorization_requests": {
+                    "description": "Only use pushed authorization requests (RFC 9126 section 6)",
+                    "type": "boolean"
+                },
+                "require_signed_request_object": {
+                    "type": "boolean"
+                },
+                "response_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scope": {
                     "type": "string"
                 },
-                "name": {
+                "tls_client_auth_subject_dn": {
+                    "description": "Mutual-TLS metadata (RFC 8705 section 2.1.2 and 3.4)",
+                    "type": "string"
+                },
+                "tls_client_certificate_bound_access_tokens": {
+                    "type": "boolean"
+                },
+                "token_endpoint_auth_method": {
                     "type": "string"
                 }
             }
         },
-        "entities.Application": {
+        "registration_dtos.ClientMetadata": {
             "type": "object",
             "properties": {
-                "client_id": {
+                "application_type": {
+                    "description": "web or native (OpenID Connect Dynamic Client Registration), defaults to web",
                     "type": "string"
                 },
-                "client_secret": {
+                "backchannel_logout_session_required": {
+                    "type": "boolean"
+                },
+                "backchannel_logout_uri": {
+                    "description": "OpenID Connect Back-Channel and Front-Channel Logout 1.0 section 2.2",
+                    "type": "string"
+                },
+                "client_name": {
+                    "type": "string"
+                },
+                "client_uri": {
+                    "type": "string"
+                },
+                "contacts": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.ClientSecret"
+                        "type": "string"
                     }
                 },
-                "created_at": {
-                    "type": "integer"
+                "dpop_bound_access_tokens": {
+                    "description": "Always use DPoP at the token endpoint (RFC 9449 section 5.2)",
+                    "type": "boolean"
                 },
-                "description": {
+                "frontchannel_logout_session_required": {
+                    "type": "boolean"
+                },
+                "frontchannel_logout_uri": {
                     "type": "string"
                 },
-                "id": {
+                "grant_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "jwks": {
+                    "description": "Keys of private_key_jwt clients, inline or by URL but not both",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/jwk_dtos.JWKSet"
+                        }
+                    ]
+                },
+                "jwks_uri": {
                     "type": "string"
                 },
-                "name": {
+                "logo_uri": {
                     "type": "string"
                 },
+                "post_logout_redirect_uris": {
+                    "description": "OpenID Connect RP-Initiated Logout 1.0 section 3.1",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "redirect_uris": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "resource_servers": {
+                "request_uris": {
+                    "description": "Request object metadata (RFC 9101 section 10.2)",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.ResourceServer"
+                        "type": "string"
                     }
                 },
-                "scopes": {
+                "require_pushed_authorization_requests": {
+                    "description": "Only use pushed authorization requests (RFC 9126 section 6)",
+                    "type": "boolean"
+                },
+                "require_signed_request_object": {
+                    "type": "boolean"
+                },
+                "response_types": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "updated_at": {
-                    "type": "integer"
+                "scope": {
+                    "type": "string"
+                },
+                "tls_client_auth_subject_dn": {
+                    "description": "Mutual-TLS metadata (RFC 8705 section 2.1.2 and 3.4)",
+                    "type": "string"
+                },
+                "tls_client_certificate_bound_access_tokens": {
+                    "type": "boolean"
+                },
+                "token_endpoint_auth_method": {
+                    "type": "string"
                 }
             }
         },
-        "entities.ClientSecret": {
+        "registration_dtos.ClientUpdateRequest": {
             "type": "object",
+            "required": [
+                "client_id"
+            ],
             "properties": {
-                "created_at": {
-                    "type": "integer"
+                "application_type": {
+                    "description": "web or native (OpenID Connect Dynamic Client Registration), defaults to web",
+                    "type": "string"
                 },
-                "expire_at": {
-                    "type": "integer"
+                "backchannel_logout_session_required": {
+                    "type": "boolean"
                 },
-                "name": {
+                "backchannel_logout_uri": {
+                    "description": "OpenID Connect Back-Channel and Front-Channel Logout 1.0 section 2.2",
                     "type": "string"
                 },
-                "value": {
+                "client_id": {
                     "type": "string"
-                }
-            }
-        },
-        "entities.ResourceServer": {
-            "type": "object",
-            "properties": {
-                "created_at": {
-                    "type": "integer"
                 },
-                "description": {
+                "client_name": {
                     "type": "string"
                 },
-                "display_name": {
+                "client_uri": {
                     "type": "string"
                 },
-                "id": {
+                "contacts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "dpop_bound_access_tokens": {
+                    "description": "Always use DPoP at the token endpoint (RFC 9449 section 5.2)",
+                    "type": "boolean"
+                },
+                "frontchannel_logout_session_required": {
+                    "type": "boolean"
+                },
+                "frontchannel_logout_uri": {
                     "type": "string"
                 },
-                "name": {
+                "grant_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "jwks": {
+                    "description": "Keys of private_key_jwt clients, inline or by URL but not both",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/jwk_dtos.JWKSet"
+                        }
+                    ]
+                },
+                "jwks_uri": {
                     "type": "string"
                 },
-                "updated_at": {
-                    "type": "integer"
+                "logo_uri": {
+                    "type": "string"
+                },
+                "post_logout_redirect_uris": {
+                    "description": "OpenID Connect RP-Initiated Logout 1.0 section 3.1",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "redirect_uris": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "request_uris": {
+                    "description": "Request object metadata (RFC 9101 section 10.2)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "require_pushed_authorization_requests": {
+                    "description": "Only use pushed authorization requests (RFC 9126 section 6)",
+                    "type": "boolean"
+                },
+                "require_signed_request_object": {
+                    "type": "boolean"
+                },
+                "response_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "tls_client_auth_subject_dn": {
+                    "description": "Mutual-TLS metadata (RFC 8705 section 2.1.2 and 3.4)",
+                    "type": "string"
+                },
+                "tls_client_certificate_bound_access_tokens": {
+                    "type": "boolean"
+                },
+                "token_endpoint_auth_method": {
+                    "type": "string"
                 }
             }
         },
-        "entities.User": {
+        "resource_server_dtos.CreateResourceServerDTO": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "integer"
-                },
-                "email": {
+                "description": {
                     "type": "string"
                 },
-                "id": {
+                "display_name": {
                     "type": "string"
                 },
-                "password": {
+                "identifier": {
+                    "description": "Absolute URI used as token audience, generated from the name when omitted",
                     "type": "string"
                 },
-                "updated_at": {
-                    "type": "integer"
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/resource_server_dtos.ScopeDTO"
+                    }
                 }
             }
         },
-        "resource_server_dtos.CreateResourceServerDTO": {
+        "resource_server_dtos.ScopeDTO": {
             "type": "object",
+            "required": [
+                "value"
+            ],
             "properties": {
                 "description": {
                     "type": "string"
                 },
-                "display_name": {
+                "value": {
                     "type": "string"
                 }
             }
@@ -643,6 +3697,13 @@ const docTemplate = `{
                 },
                 "display_name": {
                     "type": "string"
+                },
+                "scopes": {
+                    "description": "Replaces the scope definitions when set",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/resource_server_dtos.ScopeDTO"
+                    }
                 }
             }
         },
@@ -655,6 +3716,81 @@ const docTemplate = `{
                 "expires_at": {
                     "type": "integer"
                 },
+                "expires_in": {
+                    "type": "integer"
+                },
+                "id_token": {
+                    "type": "string"
+                },
+                "issued_token_type": {
+                    "description": "Only set for token exchange responses",
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "token_dtos.Confirmation": {
+            "type": "object",
+            "properties": {
+                "jkt": {
+                    "description": "JWK thumbprint of the DPoP key (RFC 9449 section 6.1)",
+                    "type": "string"
+                },
+                "x5t#S256": {
+                    "description": "SHA-256 thumbprint of the client certificate (RFC 8705 section 3.1)",
+                    "type": "string"
+                }
+            }
+        },
+        "token_dtos.IntrospectionResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "aud": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "cnf": {
+                    "description": "Key the token is bound to (RFC 8705 section 3.2)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/token_dtos.Confirmation"
+                        }
+                    ]
+                },
+                "exp": {
+                    "type": "integer"
+                },
+                "iat": {
+                    "type": "integer"
+                },
+                "iss": {
+                    "type": "string"
+                },
+                "jti": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "sub": {
+                    "type": "string"
+                },
                 "token_type": {
                     "type": "string"
                 }
@@ -674,18 +3810,43 @@ const docTemplate = `{
         "token_dtos.JWTPayload": {
             "type": "object",
             "properties": {
+                "act": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
                 "aud": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "client_id": {
                     "type": "string"
                 },
+                "cnf": {
+                    "$ref": "#/definitions/token_dtos.Confirmation"
+                },
                 "exp": {
                     "type": "integer"
                 },
                 "header": {
                     "$ref": "#/definitions/token_dtos.JWTHeader"
                 },
+                "iat": {
+                    "type": "integer"
+                },
                 "iss": {
                     "type": "string"
                 },
+                "jti": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "sid": {
+                    "type": "string"
+                },
                 "sub": {
                     "type": "string"
                 }
@@ -705,9 +3866,49 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
+                "family_name": {
+                    "type": "string"
+                },
+                "given_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                }
+            }
+        },
+        "user_dtos.UpdateUserDTO": {
+            "type": "object",
+            "properties": {
+                "confirm_password": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "family_name": {
+                    "type": "string"
+                },
+                "given_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
                 "password": {
                     "type": "string",
                     "minLength": 8
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "disabled"
+                    ]
                 }
             }
         }
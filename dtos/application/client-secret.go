@@ -0,0 +1,11 @@
+package application_dtos
+
+// A newly created client secret, the plaintext is only returned once
+type ClientSecretResponse struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	ClientSecret string `json:"client_secret"`
+	Hint         string `json:"hint"`
+	ExpireAt     int64  `json:"expire_at"`
+	CreatedAt    int64  `json:"created_at"`
+}
@@ -0,0 +1,7 @@
+package application_dtos
+
+type CreateClientSecretDTO struct {
+	Name string `json:"name" binding:"required"`
+	// Unix timestamp after which the secret is rejected, 0 for no expiry
+	ExpireAt int64 `json:"expire_at"`
+}
@@ -0,0 +1,36 @@
+package application_dtos
+
+import jwk_dtos "github.com/keyloom/web-api/dtos/jwk"
+
+// Omitted keys, URIs and flags are kept
+type UpdateApplicationDTO struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	// spa, native, web or m2m, kept when omitted
+	Type string `json:"type" binding:"omitempty,oneof=spa native web m2m"`
+	// Default to those of the application type when it changes
+	GrantTypes              []string `json:"grant_types"`
+	TokenEndpointAuthMethod string   `json:"token_endpoint_auth_method"`
+	// Keys of private_key_jwt applications, inline or by URL
+	JWKS    *jwk_dtos.JWKSet `json:"jwks"`
+	JWKSURI string           `json:"jwks_uri"`
+	// Subject DN of the certificate of tls_client_auth applications
+	TLSClientAuthSubjectDN string `json:"tls_client_auth_subject_dn"`
+	// Bind access tokens to the client certificate
+	TLSClientCertificateBoundAccessTokens *bool `json:"tls_client_certificate_bound_access_tokens"`
+	// Require DPoP proofs at the token endpoint
+	DPoPBoundAccessTokens *bool `json:"dpop_bound_access_tokens"`
+	// Only accept authorization requests pushed to /par
+	RequirePushedAuthorizationRequests *bool `json:"require_pushed_authorization_requests"`
+	// URLs the application publishes request objects at
+	RequestURIs []string `json:"request_uris"`
+	// Reject authorization requests without a signed request object
+	RequireSignedRequestObject *bool `json:"require_signed_request_object"`
+	// URIs users may be sent back to after signing out
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris"`
+	// Notified when a session the application took part in ends
+	BackchannelLogoutURI              string `json:"backchannel_logout_uri"`
+	BackchannelLogoutSessionRequired  *bool  `json:"backchannel_logout_session_required"`
+	FrontchannelLogoutURI             string `json:"frontchannel_logout_uri"`
+	FrontchannelLogoutSessionRequired *bool  `json:"frontchannel_logout_session_required"`
+}
@@ -0,0 +1,21 @@
+package authorize_dtos
+
+type AuthorizationRequestDTO struct {
+	ResponseType        string   `form:"response_type"`
+	ClientID            string   `form:"client_id"`
+	RedirectURI         string   `form:"redirect_uri"`
+	Scope               string   `form:"scope"`
+	State               string   `form:"state"`
+	CodeChallenge       string   `form:"code_challenge"`
+	CodeChallengeMethod string   `form:"code_challenge_method"`
+	Nonce               string   `form:"nonce"`
+	Resources           []string `form:"resource"`
+	Prompt              string   `form:"prompt"`
+	// Maximum age in seconds of the authentication of the user
+	MaxAge *int64 `form:"max_age" binding:"omitempty,min=0"`
+	// Request object passed by value (RFC 9101)
+	Request string `form:"request"`
+	// Reference to a pushed authorization request (RFC 9126) or to a request
+	// object published by the client (RFC 9101)
+	RequestURI string `form:"request_uri"`
+}
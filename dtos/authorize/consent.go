@@ -0,0 +1,7 @@
+package authorize_dtos
+
+type ConsentDTO struct {
+	RequestID string `form:"request_id" binding:"required"`
+	CSRFToken string `form:"csrf_token" binding:"required"`
+	Action    string `form:"action" binding:"required,oneof=approve deny"`
+}
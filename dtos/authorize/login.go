@@ -0,0 +1,8 @@
+package authorize_dtos
+
+type LoginDTO struct {
+	RequestID string `form:"request_id" binding:"required"`
+	CSRFToken string `form:"csrf_token" binding:"required"`
+	Username  string `form:"username" binding:"required"`
+	Password  string `form:"password" binding:"required"`
+}
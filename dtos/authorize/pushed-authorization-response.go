@@ -0,0 +1,7 @@
+package authorize_dtos
+
+// Pushed authorization response as defined by RFC 9126 section 2.2
+type PushedAuthorizationResponse struct {
+	RequestURI string `json:"request_uri"`
+	ExpiresIn  int64  `json:"expires_in"`
+}
@@ -0,0 +1,8 @@
+package device_dtos
+
+type DeviceVerificationDTO struct {
+	UserCode string `form:"user_code" binding:"required"`
+	Username string `form:"username" binding:"required"`
+	Password string `form:"password" binding:"required"`
+	Action   string `form:"action" binding:"required,oneof=approve deny"`
+}
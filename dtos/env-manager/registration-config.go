@@ -0,0 +1,9 @@
+package envmanager_dtos
+
+type RegistrationConfig struct {
+	// Bearer token required to register clients, registration is open when empty
+	InitialAccessToken string
+	// Lets the client URLs Keyloom fetches or posts to use plain HTTP and
+	// reach loopback and private addresses, for development
+	AllowPrivateURLs bool
+}
@@ -0,0 +1,6 @@
+package envmanager_dtos
+
+type SigningKeyConfig struct {
+	Algorithm        string
+	RotationInterval int // in minutes
+}
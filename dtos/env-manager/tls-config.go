@@ -0,0 +1,9 @@
+package envmanager_dtos
+
+type TLSConfig struct {
+	// Certificate and key of the server, TLS is disabled when empty
+	CertFile string
+	KeyFile  string
+	// PEM bundle of the CAs issuing the certificates of tls_client_auth clients
+	ClientCAFile string
+}
@@ -1,8 +1,9 @@
 package envmanager_dtos
 
 type TokenConfig struct {
-	SecretKey     string
-	Issuer        string
-	Audience      string
-	TokenDuration int // in minutes
+	SecretKey            string
+	Issuer               string
+	Audience             string
+	TokenDuration        int // in minutes
+	RefreshTokenDuration int // in minutes
 }
@@ -0,0 +1,7 @@
+package grant_dtos
+
+type CreateGrantDTO struct {
+	UserID        string   `json:"user_id" binding:"required"`
+	ApplicationID string   `json:"application_id" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"`
+}
@@ -0,0 +1,7 @@
+package grant_dtos
+
+// Filters of the grant list, both optional
+type GrantFilterDTO struct {
+	UserID        string `form:"user_id"`
+	ApplicationID string `form:"application_id"`
+}
@@ -0,0 +1,5 @@
+package grant_dtos
+
+type UpdateGrantDTO struct {
+	Scopes []string `json:"scopes" binding:"required"`
+}
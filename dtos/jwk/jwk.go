@@ -0,0 +1,23 @@
+package jwk_dtos
+
+// JSON Web Key as defined by RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA public key parameters
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP public key parameters
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	// Certificate chain of the key, base64 DER encoded
+	X5c []string `json:"x5c,omitempty"`
+}
+
+// JSON Web Key Set as defined by RFC 7517
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
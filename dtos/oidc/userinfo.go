@@ -0,0 +1,12 @@
+package oidc_dtos
+
+// Claims returned by the UserInfo endpoint
+type UserInfoResponse struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	Name          string `json:"name,omitempty"`
+	GivenName     string `json:"given_name,omitempty"`
+	FamilyName    string `json:"family_name,omitempty"`
+	UpdatedAt     int64  `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,13 @@
+package registration_dtos
+
+// Client information response (RFC 7591 section 3.2.1, RFC 7592 section 3)
+type ClientInformationResponse struct {
+	ClientID         string `json:"client_id"`
+	ClientSecret     string `json:"client_secret,omitempty"`
+	ClientIDIssuedAt int64  `json:"client_id_issued_at"`
+	// Zero when the secret does not expire, only set along with a secret
+	ClientSecretExpiresAt   *int64 `json:"client_secret_expires_at,omitempty"`
+	RegistrationAccessToken string `json:"registration_access_token,omitempty"`
+	RegistrationClientURI   string `json:"registration_client_uri"`
+	ClientMetadata
+}
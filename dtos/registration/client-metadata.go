@@ -0,0 +1,44 @@
+package registration_dtos
+
+import jwk_dtos "github.com/keyloom/web-api/dtos/jwk"
+
+// Client metadata as defined by RFC 7591 section 2
+type ClientMetadata struct {
+	ClientName string `json:"client_name"`
+	// web or native (OpenID Connect Dynamic Client Registration), defaults to web
+	ApplicationType         string   `json:"application_type,omitempty"`
+	RedirectURIs            []string `json:"redirect_uris"`
+	GrantTypes              []string `json:"grant_types"`
+	ResponseTypes           []string `json:"response_types"`
+	TokenEndpointAuthMethod string   `json:"token_endpoint_auth_method"`
+	Scope                   string   `json:"scope,omitempty"`
+	ClientURI               string   `json:"client_uri,omitempty"`
+	LogoURI                 string   `json:"logo_uri,omitempty"`
+	Contacts                []string `json:"contacts,omitempty"`
+	// Keys of private_key_jwt clients, inline or by URL but not both
+	JWKS    *jwk_dtos.JWKSet `json:"jwks,omitempty"`
+	JWKSURI string           `json:"jwks_uri,omitempty"`
+	// Mutual-TLS metadata (RFC 8705 section 2.1.2 and 3.4)
+	TLSClientAuthSubjectDN                string `json:"tls_client_auth_subject_dn,omitempty"`
+	TLSClientCertificateBoundAccessTokens bool   `json:"tls_client_certificate_bound_access_tokens,omitempty"`
+	// Always use DPoP at the token endpoint (RFC 9449 section 5.2)
+	DPoPBoundAccessTokens bool `json:"dpop_bound_access_tokens,omitempty"`
+	// Only use pushed authorization requests (RFC 9126 section 6)
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests,omitempty"`
+	// Request object metadata (RFC 9101 section 10.2)
+	RequestURIs                []string `json:"request_uris,omitempty"`
+	RequireSignedRequestObject bool     `json:"require_signed_request_object,omitempty"`
+	// OpenID Connect RP-Initiated Logout 1.0 section 3.1
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris,omitempty"`
+	// OpenID Connect Back-Channel and Front-Channel Logout 1.0 section 2.2
+	BackchannelLogoutURI              string `json:"backchannel_logout_uri,omitempty"`
+	BackchannelLogoutSessionRequired  bool   `json:"backchannel_logout_session_required,omitempty"`
+	FrontchannelLogoutURI             string `json:"frontchannel_logout_uri,omitempty"`
+	FrontchannelLogoutSessionRequired bool   `json:"frontchannel_logout_session_required,omitempty"`
+}
+
+// Client update request (RFC 7592 section 2.2)
+type ClientUpdateRequest struct {
+	ClientID string `json:"client_id" binding:"required"`
+	ClientMetadata
+}
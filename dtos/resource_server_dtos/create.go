@@ -3,4 +3,7 @@ package resource_server_dtos
 type CreateResourceServerDTO struct {
 	DisplayName string `json:"display_name"`
 	Description string `json:"description"`
+	// Absolute URI used as token audience, generated from the name when omitted
+	Identifier string     `json:"identifier"`
+	Scopes     []ScopeDTO `json:"scopes" binding:"dive"`
 }
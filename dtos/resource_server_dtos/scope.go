@@ -0,0 +1,6 @@
+package resource_server_dtos
+
+type ScopeDTO struct {
+	Value       string `json:"value" binding:"required"`
+	Description string `json:"description"`
+}
@@ -3,4 +3,6 @@ package resource_server_dtos
 type UpdateResourceServerDTO struct {
 	DisplayName string `json:"display_name"`
 	Description string `json:"description"`
+	// Replaces the scope definitions when set
+	Scopes []ScopeDTO `json:"scopes" binding:"dive"`
 }
@@ -0,0 +1,9 @@
+package session_dtos
+
+// RP-initiated logout request (OpenID Connect RP-Initiated Logout 1.0)
+type LogoutDTO struct {
+	IDTokenHint           string `form:"id_token_hint"`
+	ClientID              string `form:"client_id"`
+	PostLogoutRedirectURI string `form:"post_logout_redirect_uri"`
+	State                 string `form:"state"`
+}
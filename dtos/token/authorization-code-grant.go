@@ -0,0 +1,8 @@
+package token_dtos
+
+type AuthorizationCodeGrantRequest struct {
+	Code         string   `form:"code" binding:"required"`
+	RedirectURI  string   `form:"redirect_uri"`
+	CodeVerifier string   `form:"code_verifier" binding:"required"`
+	Resources    []string `form:"resource"`
+}
@@ -0,0 +1,9 @@
+package token_dtos
+
+// Confirmation claim binding a token to a key of the client (RFC 7800)
+type Confirmation struct {
+	// SHA-256 thumbprint of the client certificate (RFC 8705 section 3.1)
+	X5tS256 string `json:"x5t#S256,omitempty"`
+	// JWK thumbprint of the DPoP key (RFC 9449 section 6.1)
+	Jkt string `json:"jkt,omitempty"`
+}
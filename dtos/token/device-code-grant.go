@@ -0,0 +1,6 @@
+package token_dtos
+
+type DeviceCodeGrantRequest struct {
+	DeviceCode string   `form:"device_code" binding:"required"`
+	Resources  []string `form:"resource"`
+}
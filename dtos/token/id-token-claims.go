@@ -0,0 +1,12 @@
+package token_dtos
+
+// Claims used to build an OpenID Connect ID token
+type IDTokenClaims struct {
+	Subject     string
+	ClientID    string
+	Nonce       string
+	AuthTime    int64
+	AccessToken string
+	// Identifier of the browser session the user signed in with
+	SessionID string
+}
@@ -7,8 +7,15 @@ type JWTHeader struct {
 
 type JWTPayload struct {
 	JWTHeader `json:"header"`
-	Sub       string `json:"sub"`
-	Iss       string `json:"iss"`
-	Aud       string `json:"aud"`
-	Exp       int64  `json:"exp"`
+	Sub       string                 `json:"sub"`
+	Iss       string                 `json:"iss"`
+	Aud       []string               `json:"aud"`
+	Exp       int64                  `json:"exp"`
+	Iat       int64                  `json:"iat,omitempty"`
+	Jti       string                 `json:"jti,omitempty"`
+	Act       map[string]interface{} `json:"act,omitempty"`
+	ClientID  string                 `json:"client_id,omitempty"`
+	Scope     string                 `json:"scope,omitempty"`
+	Cnf       *Confirmation          `json:"cnf,omitempty"`
+	Sid       string                 `json:"sid,omitempty"`
 }
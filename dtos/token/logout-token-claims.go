@@ -0,0 +1,8 @@
+package token_dtos
+
+// Claims used to build a back-channel logout token
+type LogoutTokenClaims struct {
+	Subject   string
+	ClientID  string
+	SessionID string
+}
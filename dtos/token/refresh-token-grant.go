@@ -0,0 +1,7 @@
+package token_dtos
+
+type RefreshTokenGrantRequest struct {
+	RefreshToken string   `form:"refresh_token" binding:"required"`
+	Scope        string   `form:"scope"`
+	Resources    []string `form:"resource"`
+}
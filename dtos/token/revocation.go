@@ -0,0 +1,6 @@
+package token_dtos
+
+type RevocationRequest struct {
+	Token         string `form:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+}
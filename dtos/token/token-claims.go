@@ -0,0 +1,15 @@
+package token_dtos
+
+// Claims used to build an access token
+type TokenClaims struct {
+	Subject  string
+	ClientID string
+	Audience []string
+	Scopes   []string
+	// Delegation chain of the token (RFC 8693 section 4.1)
+	Act map[string]interface{}
+	// Caps the expiration time, as a unix timestamp, when set
+	NotAfter int64
+	// Key the token is bound to, if any
+	Confirmation *Confirmation
+}
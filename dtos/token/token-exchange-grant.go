@@ -0,0 +1,12 @@
+package token_dtos
+
+type TokenExchangeGrantRequest struct {
+	SubjectToken       string `form:"subject_token" binding:"required"`
+	SubjectTokenType   string `form:"subject_token_type" binding:"required"`
+	ActorToken         string `form:"actor_token"`
+	ActorTokenType     string `form:"actor_token_type"`
+	Resource           string `form:"resource"`
+	Audience           string `form:"audience"`
+	Scope              string `form:"scope"`
+	RequestedTokenType string `form:"requested_token_type"`
+}
@@ -4,4 +4,7 @@ type CreateUserDTO struct {
 	Email           string `json:"email" binding:"required,email"`
 	Password        string `json:"password" binding:"required,containsany=uppercase,containsany=lowercase,containsany=numeric,min=8"`
 	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=Password"`
+	Name            string `json:"name"`
+	GivenName       string `json:"given_name"`
+	FamilyName      string `json:"family_name"`
 }
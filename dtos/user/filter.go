@@ -0,0 +1,11 @@
+package user_dtos
+
+// Filters of the user list, all optional
+type UserFilterDTO struct {
+	// Part of the email, case insensitive
+	Email  string `form:"email"`
+	Status string `form:"status" binding:"omitempty,oneof=active disabled"`
+	// Unix timestamps bounding the creation date
+	CreatedAfter  int64 `form:"created_after"`
+	CreatedBefore int64 `form:"created_before"`
+}
@@ -0,0 +1,12 @@
+package user_dtos
+
+// Omitted fields are kept
+type UpdateUserDTO struct {
+	Email           string  `json:"email" binding:"omitempty,email"`
+	Password        string  `json:"password" binding:"omitempty,containsany=uppercase,containsany=lowercase,containsany=numeric,min=8"`
+	ConfirmPassword string  `json:"confirm_password" binding:"required_with=Password,eqfield=Password"`
+	Status          string  `json:"status" binding:"omitempty,oneof=active disabled"`
+	Name            *string `json:"name"`
+	GivenName       *string `json:"given_name"`
+	FamilyName      *string `json:"family_name"`
+}
@@ -1,32 +1,90 @@
 package entities
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/keyloom/web-api/core"
+	jwk_dtos "github.com/keyloom/web-api/dtos/jwk"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+// A client secret, only its hash is stored. Several secrets can be valid at
+// the same time so they can be rotated without downtime.
 type ClientSecret struct {
-	Name      string `bson:"name" json:"name"`
-	Value     string `bson:"value" json:"value"`
+	ID    string `bson:"id" json:"id"`
+	Name  string `bson:"name" json:"name"`
+	Value string `bson:"value" json:"-"`
+	// Only kept, encrypted, for client_secret_jwt clients that sign with it
+	EncryptedValue string `bson:"encrypted_value,omitempty" json:"-"`
+	// Last characters of the plaintext, to tell secrets apart
+	Hint      string `bson:"hint" json:"hint"`
 	ExpireAt  int64  `bson:"expire_at" json:"expire_at"`
 	CreatedAt int64  `bson:"created_at" json:"created_at"`
 }
 
+// Reports whether the secret has expired
+func (s *ClientSecret) IsExpired() bool {
+	return s.ExpireAt != 0 && s.ExpireAt <= time.Now().Unix()
+}
+
 type Application struct {
 	core.Entity       `bson:",inline" json:",inline"`
 	Name              string               `bson:"name" json:"name"`
 	Description       string               `bson:"description" json:"description"`
 	ClientID          string               `bson:"client_id" json:"client_id"`
-	ClientSecrets     []ClientSecret       `bson:"client_secret" json:"client_secret"`
+	ClientSecrets     []ClientSecret       `bson:"client_secret" json:"client_secrets"`
 	RedirectURIs      []string             `bson:"redirect_uris" json:"redirect_uris"`
 	Scopes            []string             `bson:"scopes" json:"scopes"`
 	ResourceServerIDs []primitive.ObjectID `bson:"resource_server_ids" json:"-"`
 	ResourceServers   []*ResourceServer    `bson:"-" json:"resource_servers,omitempty"`
+	// One of core.ApplicationTypes, bounds the grant types and authentication method
+	Type string `bson:"type" json:"type"`
+	// Client metadata (RFC 7591 section 2)
+	GrantTypes              []string `bson:"grant_types" json:"grant_types"`
+	ResponseTypes           []string `bson:"response_types" json:"response_types"`
+	TokenEndpointAuthMethod string   `bson:"token_endpoint_auth_method" json:"token_endpoint_auth_method"`
+	ClientURI               string   `bson:"client_uri" json:"client_uri,omitempty"`
+	LogoURI                 string   `bson:"logo_uri" json:"logo_uri,omitempty"`
+	Contacts                []string `bson:"contacts" json:"contacts,omitempty"`
+	// Keys of private_key_jwt clients, registered inline or published at a URL
+	JWKS    *jwk_dtos.JWKSet `bson:"jwks,omitempty" json:"jwks,omitempty"`
+	JWKSURI string           `bson:"jwks_uri" json:"jwks_uri,omitempty"`
+	// Subject DN of the certificate of tls_client_auth clients (RFC 8705)
+	TLSClientAuthSubjectDN string `bson:"tls_client_auth_subject_dn" json:"tls_client_auth_subject_dn,omitempty"`
+	// Bind the access tokens of the client to its certificate
+	TLSClientCertificateBoundAccessTokens bool `bson:"tls_client_certificate_bound_access_tokens" json:"tls_client_certificate_bound_access_tokens"`
+	// Require DPoP proofs at the token endpoint (RFC 9449 section 5.2)
+	DPoPBoundAccessTokens bool `bson:"dpop_bound_access_tokens" json:"dpop_bound_access_tokens"`
+	// Only accept authorization requests pushed to /par (RFC 9126 section 6)
+	RequirePushedAuthorizationRequests bool `bson:"require_pushed_authorization_requests" json:"require_pushed_authorization_requests"`
+	// URLs the client publishes request objects at (RFC 9101 section 5.2)
+	RequestURIs []string `bson:"request_uris" json:"request_uris"`
+	// Reject authorization requests without a signed request object
+	RequireSignedRequestObject bool `bson:"require_signed_request_object" json:"require_signed_request_object"`
+	// URIs the user may be sent back to after signing out
+	PostLogoutRedirectURIs []string `bson:"post_logout_redirect_uris" json:"post_logout_redirect_uris"`
+	// Notified when a session the client took part in ends, from the server
+	// (OpenID Connect Back-Channel Logout) or from the browser of the user
+	// (OpenID Connect Front-Channel Logout). The session required flags add
+	// the issuer and session ID.
+	BackchannelLogoutURI              string `bson:"backchannel_logout_uri" json:"backchannel_logout_uri,omitempty"`
+	BackchannelLogoutSessionRequired  bool   `bson:"backchannel_logout_session_required" json:"backchannel_logout_session_required"`
+	FrontchannelLogoutURI             string `bson:"frontchannel_logout_uri" json:"frontchannel_logout_uri,omitempty"`
+	FrontchannelLogoutSessionRequired bool   `bson:"frontchannel_logout_session_required" json:"frontchannel_logout_session_required"`
+	// Digest of the token managing a dynamically registered client (RFC 7592)
+	RegistrationAccessToken string `bson:"registration_access_token" json:"-"`
 }
 
 var _ core.IEntity[Application] = (*Application)(nil)
@@ -47,6 +105,12 @@ func (a *Application) CreateNew() *Application {
 		Scopes:            []string{},
 		ResourceServerIDs: []primitive.ObjectID{},
 		ResourceServers:   []*ResourceServer{},
+		GrantTypes:        []string{},
+		ResponseTypes:     []string{},
+		Contacts:          []string{},
+		RequestURIs:       []string{},
+
+		PostLogoutRedirectURIs: []string{},
 	}
 }
 
@@ -84,12 +148,16 @@ func (a *Application) LoadAll(top, page int) []*Application {
 
 func (a *Application) LoadByID(id string) *Application {
 	client := core.NewMongoClient()
-	result := client.FindOne(a.CollectionName(), map[string]interface{}{"_id": id})
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil
+	}
+	result := client.FindOne(a.CollectionName(), bson.M{"_id": oid})
 	if result.Err() != nil {
 		return nil
 	}
 	var application Application
-	err := result.Decode(&application)
+	err = result.Decode(&application)
 	if err != nil {
 		return nil
 	}
@@ -106,8 +174,16 @@ func (a *Application) LoadByID(id string) *Application {
 // Loads multiple applications by their IDs
 func (a *Application) LoadByIDs(ids []string) []*Application {
 	client := core.NewMongoClient()
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			continue
+		}
+		oids = append(oids, oid)
+	}
 	cursor, err := client.FindMany(a.CollectionName(), bson.M{
-		"_id": bson.M{"$in": ids},
+		"_id": bson.M{"$in": oids},
 	})
 	if err != nil {
 		return nil
@@ -168,6 +244,460 @@ func (a *Application) LoadByName(name string) *Application {
 	return &application
 }
 
+// Loads an application by its OAuth client ID
+func (a *Application) LoadByClientID(clientID string) *Application {
+	client := core.NewMongoClient()
+	result := client.FindOne(a.CollectionName(), bson.M{"client_id": clientID})
+	if result.Err() != nil {
+		return nil
+	}
+	var application Application
+	err := result.Decode(&application)
+	if err != nil {
+		return nil
+	}
+	resourceServerEntity := &ResourceServer{}
+	stringIds := make([]string, len(application.ResourceServerIDs))
+	for i, id := range application.ResourceServerIDs {
+		stringIds[i] = id.Hex()
+	}
+	resourceServers := resourceServerEntity.LoadByIDs(stringIds)
+	application.ResourceServers = resourceServers
+	return &application
+}
+
+// Checks the given secret against the application's client secrets.
+// Expired secrets are ignored.
+func (a *Application) CheckClientSecret(secret string) bool {
+	if secret == "" {
+		return false
+	}
+	hasher := &core.Hasher{}
+	for _, clientSecret := range a.ClientSecrets {
+		if clientSecret.IsExpired() {
+			continue
+		}
+		if hasher.Compare(clientSecret.Value, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// Generates a new client secret and adds its hash to the application.
+// The plaintext secret is returned and cannot be recovered afterwards.
+func (a *Application) AddClientSecret(name string, expireAt int64) (string, *ClientSecret, error) {
+	id, err := core.GenerateRandomString(9)
+	if err != nil {
+		return "", nil, err
+	}
+	plaintext, err := core.GenerateRandomString(32)
+	if err != nil {
+		return "", nil, err
+	}
+	hash, err := (&core.Hasher{}).Hash(plaintext)
+	if err != nil {
+		return "", nil, err
+	}
+	// client_secret_jwt clients sign with the secret, so it must be recoverable
+	encrypted := ""
+	if a.TokenEndpointAuthMethod == core.ClientSecretJWTAuthMethod {
+		encrypted, err = (&core.Cipher{}).Encrypt([]byte(plaintext))
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	a.ClientSecrets = append(a.ClientSecrets, ClientSecret{
+		ID:             id,
+		Name:           name,
+		Value:          hash,
+		EncryptedValue: encrypted,
+		Hint:           plaintext[len(plaintext)-4:],
+		ExpireAt:       expireAt,
+		CreatedAt:      time.Now().Unix(),
+	})
+	return plaintext, &a.ClientSecrets[len(a.ClientSecrets)-1], nil
+}
+
+// Returns the keys the client may sign its assertions with: the public keys
+// of its JWKS for private_key_jwt, its unexpired secrets for
+// client_secret_jwt. Keys are filtered by kid when one is given.
+func (a *Application) AssertionKeys(kid string) ([]jwt.VerificationKey, error) {
+	keys := []jwt.VerificationKey{}
+	switch a.TokenEndpointAuthMethod {
+	case core.PrivateKeyJWTAuthMethod:
+		return a.SigningKeys(kid)
+	case core.ClientSecretJWTAuthMethod:
+		cipher := &core.Cipher{}
+		for _, clientSecret := range a.ClientSecrets {
+			if clientSecret.IsExpired() || clientSecret.EncryptedValue == "" {
+				continue
+			}
+			secret, err := cipher.Decrypt(clientSecret.EncryptedValue)
+			if err != nil {
+				continue
+			}
+			keys = append(keys, secret)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no key to verify the assertions of client %s", a.ClientID)
+	}
+	return keys, nil
+}
+
+// Returns the public signing keys of the registered JWKS, filtered by kid
+// when one is given
+func (a *Application) SigningKeys(kid string) ([]jwt.VerificationKey, error) {
+	keySet, err := a.keySet(false)
+	if err != nil {
+		return nil, err
+	}
+	// a kid that is not published yet may come from a rotated key set
+	if kid != "" && a.JWKSURI != "" && !slices.ContainsFunc(keySet.Keys, func(key jwk_dtos.JWK) bool { return key.Kid == kid }) {
+		keySet, err = a.keySet(true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	keys := []jwt.VerificationKey{}
+	for _, jwk := range keySet.Keys {
+		if (kid != "" && jwk.Kid != kid) || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := core.ParseJWK(jwk)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no signing key registered for client %s", a.ClientID)
+	}
+	return keys, nil
+}
+
+// Returns the registered key set, fetching it when registered by URL
+func (a *Application) keySet(refresh bool) (jwk_dtos.JWKSet, error) {
+	if a.JWKSURI != "" {
+		return core.FetchJWKS(a.JWKSURI, refresh)
+	}
+	if a.JWKS == nil {
+		return jwk_dtos.JWKSet{}, nil
+	}
+	return *a.JWKS, nil
+}
+
+// Removes the client secret with the given ID, returns false if there is none
+func (a *Application) RemoveClientSecret(id string) bool {
+	for i, clientSecret := range a.ClientSecrets {
+		if clientSecret.ID == id {
+			a.ClientSecrets = append(a.ClientSecrets[:i], a.ClientSecrets[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Replaces the plaintext client secrets stored by earlier versions with
+// their hashes
+func (a *Application) HashClientSecrets(migration *Migration) error {
+	client := core.NewMongoClient()
+	cursor, err := client.FindMany(a.CollectionName(), bson.M{"client_secret.0": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	hasher := &core.Hasher{}
+	for cursor.Next(context.TODO()) {
+		var application Application
+		err := cursor.Decode(&application)
+		if err != nil {
+			continue
+		}
+		for i, clientSecret := range application.ClientSecrets {
+			if strings.HasPrefix(clientSecret.Value, "$2") {
+				continue
+			}
+			hash, err := hasher.Hash(clientSecret.Value)
+			if err != nil {
+				return err
+			}
+			if len(clientSecret.Value) >= 4 {
+				application.ClientSecrets[i].Hint = clientSecret.Value[len(clientSecret.Value)-4:]
+			}
+			application.ClientSecrets[i].Value = hash
+			if clientSecret.ID == "" {
+				application.ClientSecrets[i].ID, err = core.GenerateRandomString(9)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		err = application.Save()
+		if err != nil {
+			return err
+		}
+	}
+
+	migration.Changes = append(migration.Changes, core.MigrationChangeHashClientSecrets)
+	return nil
+}
+
+// Returns the identifiers of the resource servers linked to the application,
+// used as token audiences. The application must have been loaded with its
+// resource servers.
+func (a *Application) Audiences() []string {
+	audiences := []string{}
+	for _, resourceServer := range a.ResourceServers {
+		audiences = append(audiences, resourceServer.Identifier)
+	}
+	return audiences
+}
+
+// Sets the type of the application along with its grant types and token
+// endpoint authentication method. Both default to those of the type and must
+// be allowed for it.
+func (a *Application) SetType(applicationType string, grantTypes []string, authMethod string) error {
+	allowedGrantTypes, ok := core.ApplicationTypeGrantTypes[applicationType]
+	if !ok {
+		return fmt.Errorf("unknown application type: %s", applicationType)
+	}
+	if len(grantTypes) == 0 {
+		grantTypes = core.ApplicationTypeDefaultGrantTypes[applicationType]
+	}
+	for _, grantType := range grantTypes {
+		if !slices.Contains(allowedGrantTypes, grantType) {
+			return fmt.Errorf("grant type %s is not allowed for %s applications", grantType, applicationType)
+		}
+	}
+
+	allowedAuthMethods := core.ApplicationTypeAuthMethods[applicationType]
+	if authMethod == "" {
+		authMethod = allowedAuthMethods[0]
+	}
+	if !slices.Contains(allowedAuthMethods, authMethod) {
+		return fmt.Errorf("token endpoint authentication method %s is not allowed for %s applications", authMethod, applicationType)
+	}
+
+	a.Type = applicationType
+	a.GrantTypes = slices.Clone(grantTypes)
+	a.TokenEndpointAuthMethod = authMethod
+	// public clients must not hold secrets, otherwise they would be asked for them
+	if authMethod == core.NoneAuthMethod {
+		a.ClientSecrets = []ClientSecret{}
+	}
+	return nil
+}
+
+// Registers the keys of the application, inline or by URL but not both.
+// private_key_jwt and self_signed_tls_client_auth clients need one of them
+// to authenticate.
+func (a *Application) SetJWKS(jwks *jwk_dtos.JWKSet, jwksURI string) error {
+	if jwks != nil && len(jwks.Keys) == 0 {
+		jwks = nil
+	}
+	if jwks != nil && jwksURI != "" {
+		return fmt.Errorf("jwks and jwks_uri cannot both be set")
+	}
+	if jwks != nil {
+		for _, jwk := range jwks.Keys {
+			if _, err := core.ParseJWK(jwk); err != nil {
+				return fmt.Errorf("invalid key in jwks: %w", err)
+			}
+		}
+	}
+	if (a.TokenEndpointAuthMethod == core.PrivateKeyJWTAuthMethod || a.TokenEndpointAuthMethod == core.SelfSignedTLSClientAuthMethod) && jwks == nil && jwksURI == "" {
+		return fmt.Errorf("%s requires jwks or jwks_uri", a.TokenEndpointAuthMethod)
+	}
+	a.JWKS = jwks
+	a.JWKSURI = jwksURI
+	return nil
+}
+
+// Sets the request object metadata of the application. Request URIs must be
+// web URLs, signed request objects need a registered key set.
+func (a *Application) SetRequestObjects(requestURIs []string, requireSigned bool) error {
+	for _, requestURI := range requestURIs {
+		parsed, err := url.Parse(requestURI)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" || parsed.Fragment != "" {
+			return fmt.Errorf("invalid request URI: %s", requestURI)
+		}
+	}
+	if requireSigned && a.JWKS == nil && a.JWKSURI == "" {
+		return fmt.Errorf("require_signed_request_object requires jwks or jwks_uri")
+	}
+	if requestURIs == nil {
+		requestURIs = []string{}
+	}
+	a.RequestURIs = requestURIs
+	a.RequireSignedRequestObject = requireSigned
+	return nil
+}
+
+// Sets the logout notification metadata of the application. Logout URIs must
+// be web URLs without fragment.
+func (a *Application) SetLogoutURIs(backchannelURI string, backchannelSessionRequired bool, frontchannelURI string, frontchannelSessionRequired bool) error {
+	for _, logoutURI := range []string{backchannelURI, frontchannelURI} {
+		if logoutURI == "" {
+			continue
+		}
+		parsed, err := url.Parse(logoutURI)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" || parsed.Fragment != "" {
+			return fmt.Errorf("invalid logout URI: %s", logoutURI)
+		}
+	}
+	a.BackchannelLogoutURI = backchannelURI
+	a.BackchannelLogoutSessionRequired = backchannelSessionRequired
+	a.FrontchannelLogoutURI = frontchannelURI
+	a.FrontchannelLogoutSessionRequired = frontchannelSessionRequired
+	return nil
+}
+
+// Sets the mutual-TLS metadata of the application. tls_client_auth clients
+// are identified by the subject DN of their certificate.
+func (a *Application) SetTLSClientAuth(subjectDN string, boundAccessTokens bool) error {
+	if a.TokenEndpointAuthMethod == core.TLSClientAuthMethod && subjectDN == "" {
+		return fmt.Errorf("tls_client_auth requires tls_client_auth_subject_dn")
+	}
+	a.TLSClientAuthSubjectDN = subjectDN
+	a.TLSClientCertificateBoundAccessTokens = boundAccessTokens
+	return nil
+}
+
+// Checks the client certificate presented over mutual TLS. tls_client_auth
+// certificates must be issued by a trusted CA for the registered subject DN,
+// self-signed certificates must be registered in the JWKS of the client.
+func (a *Application) CheckClientCertificate(chain []*x509.Certificate) bool {
+	if len(chain) == 0 || !core.IsCertificateCurrent(chain[0]) {
+		return false
+	}
+	certificate := chain[0]
+	switch a.TokenEndpointAuthMethod {
+	case core.TLSClientAuthMethod:
+		if core.VerifyClientCertificate(chain) != nil {
+			return false
+		}
+		return certificate.Subject.String() == a.TLSClientAuthSubjectDN
+	case core.SelfSignedTLSClientAuthMethod:
+		keySet, err := a.keySet(false)
+		if err != nil {
+			return false
+		}
+		for _, jwk := range keySet.Keys {
+			if len(jwk.X5c) == 0 {
+				continue
+			}
+			registered, err := base64.StdEncoding.DecodeString(jwk.X5c[0])
+			if err == nil && bytes.Equal(registered, certificate.Raw) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Reports whether the client authenticates with mutual TLS
+func (a *Application) UsesMutualTLS() bool {
+	return a.TokenEndpointAuthMethod == core.TLSClientAuthMethod || a.TokenEndpointAuthMethod == core.SelfSignedTLSClientAuthMethod
+}
+
+// Reports whether the client authenticates with a client secret
+func (a *Application) UsesClientSecret() bool {
+	return a.TokenEndpointAuthMethod == core.ClientSecretBasicAuthMethod ||
+		a.TokenEndpointAuthMethod == core.ClientSecretPostAuthMethod ||
+		a.TokenEndpointAuthMethod == core.ClientSecretJWTAuthMethod
+}
+
+// Reports whether the application may use the grant type
+func (a *Application) AllowsGrantType(grantType string) bool {
+	return slices.Contains(a.GrantTypes, grantType)
+}
+
+// Reports whether the application is a public client that cannot authenticate
+func (a *Application) IsPublic() bool {
+	return a.TokenEndpointAuthMethod == core.NoneAuthMethod
+}
+
+// Gives a type to the applications created before application types existed.
+// Applications keep their grant types. Those holding secrets could always use
+// the client credentials grant and keep it, they become machine-to-machine
+// applications when it is all they use and web applications otherwise. The
+// others become SPAs, or native applications with the device code grant. The
+// default application keeps the password grant it has been used with.
+// Applications whose grant types fit no type are left untyped and reported.
+func (a *Application) SetApplicationTypes(migration *Migration) error {
+	client := core.NewMongoClient()
+	cursor, err := client.FindMany(a.CollectionName(), bson.M{"$or": []bson.M{
+		{"type": bson.M{"$exists": false}},
+		{"type": ""},
+	}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	for cursor.Next(context.TODO()) {
+		var application Application
+		err := cursor.Decode(&application)
+		if err != nil {
+			continue
+		}
+		grantTypes := slices.Clone(application.GrantTypes)
+		if application.Name == "keyloom-frontend" {
+			grantTypes = []string{core.CodeGrant, core.RefreshTokenGrant, core.PasswordGrant}
+		}
+		applicationType := core.SPAApplicationType
+		authMethod := ""
+		if len(application.ClientSecrets) > 0 {
+			if !slices.Contains(grantTypes, core.ClientCredentialsGrant) {
+				grantTypes = append(grantTypes, core.ClientCredentialsGrant)
+			}
+			applicationType = core.WebApplicationType
+			if !slices.ContainsFunc(grantTypes, func(grantType string) bool {
+				return !slices.Contains(core.ApplicationTypeGrantTypes[core.M2MApplicationType], grantType)
+			}) {
+				applicationType = core.M2MApplicationType
+			}
+			authMethod = application.TokenEndpointAuthMethod
+		} else if slices.Contains(grantTypes, core.DeviceCodeGrant) {
+			applicationType = core.NativeApplicationType
+		}
+		err = application.SetType(applicationType, grantTypes, authMethod)
+		if err != nil {
+			fmt.Printf("[MIGRATIONS] Application %s left untyped: %v\n", application.ClientID, err)
+			continue
+		}
+		err = application.Save()
+		if err != nil {
+			return err
+		}
+	}
+
+	migration.Changes = append(migration.Changes, core.MigrationChangeSetApplicationTypes)
+	return nil
+}
+
+// Checks the registration access token of a dynamically registered client
+func (a *Application) CheckRegistrationAccessToken(token string) bool {
+	if a.RegistrationAccessToken == "" || token == "" {
+		return false
+	}
+	digest := (&core.Hasher{}).Digest(token)
+	return subtle.ConstantTimeCompare([]byte(digest), []byte(a.RegistrationAccessToken)) == 1
+}
+
+// Returns the linked resource server with the given identifier or name, if any
+func (a *Application) LinkedResourceServer(identifierOrName string) *ResourceServer {
+	for _, resourceServer := range a.ResourceServers {
+		if resourceServer.Identifier == identifierOrName || resourceServer.Name == identifierOrName {
+			return resourceServer
+		}
+	}
+	return nil
+}
+
 func (a *Application) CreateDefaultApplication(migration *Migration) error {
 	client := core.NewMongoClient()
 	// Check if default application exists
@@ -185,18 +715,25 @@ func (a *Application) CreateDefaultApplication(migration *Migration) error {
 		"http://localhost:3000/callback",
 		"http://localhost:3000/redirect",
 	}
+	defaultApp.PostLogoutRedirectURIs = []string{
+		"http://localhost:3000",
+	}
+	err := defaultApp.SetType(core.SPAApplicationType, []string{core.CodeGrant, core.RefreshTokenGrant, core.PasswordGrant}, "")
+	if err != nil {
+		return err
+	}
 	defaultApp.Scopes = []string{
-		"keyloom:view:resource-servers",
-		"keyloom:manage:resource-servers",
-		"keyloom:view:applications",
-		"keyloom:manage:applications",
-		"keyloom:view:users",
-		"keyloom:manage:users",
-		"keyloom:view:grants",
-		"keyloom:manage:grants",
+		core.ViewResourceServersScope,
+		core.ManageResourceServersScope,
+		core.ViewApplicationsScope,
+		core.ManageApplicationsScope,
+		core.ViewUsersScope,
+		core.ManageUsersScope,
+		core.ViewGrantsScope,
+		core.ManageGrantsScope,
 	}
 
-	err := defaultApp.Save()
+	err = defaultApp.Save()
 	if err != nil {
 		return err
 	}
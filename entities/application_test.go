@@ -0,0 +1,98 @@
+package entities
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/keyloom/web-api/core"
+	jwk_dtos "github.com/keyloom/web-api/dtos/jwk"
+	"github.com/keyloom/web-api/internal/testcert"
+)
+
+// CA trusted through TLS_CLIENT_CA_FILE
+var testClientCA *x509.Certificate
+var testClientCAKey crypto.Signer
+
+func TestMain(m *testing.M) {
+	testClientCA, testClientCAKey = testcert.MustIssue(testcert.CATemplate("Keyloom Test CA"), nil, nil)
+	removeCA := testcert.MustTrustClientCA(testClientCA)
+
+	code := m.Run()
+	removeCA()
+	os.Exit(code)
+}
+
+func TestCheckClientCertificateWithTLSClientAuth(t *testing.T) {
+	certificate, _ := testcert.MustIssue(testcert.ClientTemplate("client", time.Now().Add(time.Hour)), testClientCA, testClientCAKey)
+	expired, _ := testcert.MustIssue(testcert.ClientTemplate("client", time.Now().Add(-time.Hour)), testClientCA, testClientCAKey)
+	selfSigned, _ := testcert.MustIssue(testcert.ClientTemplate("client", time.Now().Add(time.Hour)), nil, nil)
+	application := &Application{
+		TokenEndpointAuthMethod: core.TLSClientAuthMethod,
+		TLSClientAuthSubjectDN:  certificate.Subject.String(),
+	}
+	otherApplication := &Application{
+		TokenEndpointAuthMethod: core.TLSClientAuthMethod,
+		TLSClientAuthSubjectDN:  "CN=other,O=Keyloom Tests",
+	}
+
+	tests := []struct {
+		name        string
+		application *Application
+		chain       []*x509.Certificate
+		valid       bool
+	}{
+		{"registered subject DN", application, []*x509.Certificate{certificate}, true},
+		{"wrong subject DN", otherApplication, []*x509.Certificate{certificate}, false},
+		{"expired certificate", application, []*x509.Certificate{expired}, false},
+		{"certificate of an untrusted issuer", application, []*x509.Certificate{selfSigned}, false},
+		{"no certificate", application, nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.application.CheckClientCertificate(test.chain); got != test.valid {
+				t.Fatalf("expected %v, got %v", test.valid, got)
+			}
+		})
+	}
+}
+
+func TestCheckClientCertificateWithSelfSignedTLSClientAuth(t *testing.T) {
+	registered, _ := testcert.MustIssue(testcert.ClientTemplate("client", time.Now().Add(time.Hour)), nil, nil)
+	other, _ := testcert.MustIssue(testcert.ClientTemplate("client", time.Now().Add(time.Hour)), nil, nil)
+	expired, _ := testcert.MustIssue(testcert.ClientTemplate("client", time.Now().Add(-time.Hour)), nil, nil)
+	registeredKeys := func(certificates ...*x509.Certificate) *jwk_dtos.JWKSet {
+		keySet := &jwk_dtos.JWKSet{}
+		for _, certificate := range certificates {
+			keySet.Keys = append(keySet.Keys, jwk_dtos.JWK{
+				Kty: "EC",
+				X5c: []string{base64.StdEncoding.EncodeToString(certificate.Raw)},
+			})
+		}
+		return keySet
+	}
+	application := &Application{
+		TokenEndpointAuthMethod: core.SelfSignedTLSClientAuthMethod,
+		JWKS:                    registeredKeys(registered, expired),
+	}
+
+	tests := []struct {
+		name        string
+		certificate *x509.Certificate
+		valid       bool
+	}{
+		{"registered certificate", registered, true},
+		{"unregistered certificate", other, false},
+		{"expired registered certificate", expired, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := application.CheckClientCertificate([]*x509.Certificate{test.certificate}); got != test.valid {
+				t.Fatalf("expected %v, got %v", test.valid, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/keyloom/web-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// A single use authorization code issued by the authorization endpoint.
+// Only the SHA-256 digest of the code is stored.
+type AuthorizationCode struct {
+	core.Entity         `bson:",inline" json:",inline"`
+	CodeHash            string             `bson:"code_hash" json:"-"`
+	ClientID            string             `bson:"client_id" json:"client_id"`
+	UserID              primitive.ObjectID `bson:"user_id" json:"-"`
+	RedirectURI         string             `bson:"redirect_uri" json:"redirect_uri"`
+	RedirectURIProvided bool               `bson:"redirect_uri_provided" json:"-"`
+	Scopes              []string           `bson:"scopes" json:"scopes"`
+	// Resource indicators the grant is limited to
+	Resources           []string `bson:"resources" json:"resources"`
+	CodeChallenge       string   `bson:"code_challenge" json:"-"`
+	CodeChallengeMethod string   `bson:"code_challenge_method" json:"-"`
+	Nonce               string   `bson:"nonce" json:"-"`
+	AuthTime            int64    `bson:"auth_time" json:"auth_time"`
+	// Identifier of the browser session the user signed in with
+	SessionID string    `bson:"session_id" json:"-"`
+	Consumed  bool      `bson:"consumed" json:"consumed"`
+	ExpireAt  time.Time `bson:"expire_at" json:"expire_at"`
+}
+
+func (ac *AuthorizationCode) CollectionName() string {
+	return "authorization-codes"
+}
+
+func (ac *AuthorizationCode) CreateNew() *AuthorizationCode {
+	return &AuthorizationCode{
+		Entity: core.Entity{
+			ID:        primitive.NilObjectID,
+			CreatedAt: time.Now().Unix(),
+			UpdatedAt: time.Now().Unix(),
+		},
+		Scopes:   []string{},
+		ExpireAt: time.Now().Add(core.AuthorizationCodeLifetime),
+	}
+}
+
+// Generates a new code for the authorization and stores its digest.
+// The plain code is returned and never persisted.
+func (ac *AuthorizationCode) Issue() (string, error) {
+	code, err := core.GenerateRandomString(32)
+	if err != nil {
+		return "", err
+	}
+	ac.CodeHash = (&core.Hasher{}).Digest(code)
+	err = ac.Save()
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// Atomically marks the given code as consumed and returns it.
+// Returns nil if the code does not exist, was already used or has expired.
+func (ac *AuthorizationCode) Consume(code string) *AuthorizationCode {
+	client := core.NewMongoClient()
+	result := client.FindOneAndUpdate(ac.CollectionName(), bson.M{
+		"code_hash": (&core.Hasher{}).Digest(code),
+		"consumed":  false,
+	}, bson.M{
+		"$set": bson.M{"consumed": true, "updated_at": time.Now().Unix()},
+	})
+	if result.Err() != nil {
+		return nil
+	}
+	var authorizationCode AuthorizationCode
+	err := result.Decode(&authorizationCode)
+	if err != nil {
+		return nil
+	}
+	if time.Now().After(authorizationCode.ExpireAt) {
+		return nil
+	}
+	return &authorizationCode
+}
+
+func (ac *AuthorizationCode) Save() error {
+	client := core.NewMongoClient()
+	if ac.ID != primitive.NilObjectID {
+		ac.UpdatedAt = time.Now().Unix()
+		_, err := client.UpdateOne(ac.CollectionName(), bson.M{"_id": ac.ID}, bson.M{"$set": ac})
+		return err
+	} else {
+		ac.ID = primitive.NewObjectID()
+		ac.CreatedAt = time.Now().Unix()
+		ac.UpdatedAt = time.Now().Unix()
+		_, err := client.InsertOne(ac.CollectionName(), ac)
+		return err
+	}
+}
+
+// Creates the TTL index that removes expired authorization codes
+func (ac *AuthorizationCode) CreateIndexes() error {
+	client := core.NewMongoClient()
+	return client.CreateTTLIndex(ac.CollectionName(), "expire_at")
+}
@@ -0,0 +1,165 @@
+package entities
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/keyloom/web-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// A validated authorization request waiting for the end user to authenticate
+// and, when needed, to consent
+type AuthorizationRequest struct {
+	core.Entity         `bson:",inline" json:",inline"`
+	Handle              string   `bson:"handle" json:"-"`
+	ClientID            string   `bson:"client_id" json:"client_id"`
+	RedirectURI         string   `bson:"redirect_uri" json:"redirect_uri"`
+	RedirectURIProvided bool     `bson:"redirect_uri_provided" json:"-"`
+	Scopes              []string `bson:"scopes" json:"scopes"`
+	// Resource indicators the grant is limited to
+	Resources           []string `bson:"resources" json:"resources"`
+	State               string   `bson:"state" json:"state"`
+	CodeChallenge       string   `bson:"code_challenge" json:"-"`
+	CodeChallengeMethod string   `bson:"code_challenge_method" json:"-"`
+	Nonce               string   `bson:"nonce" json:"-"`
+	Prompt              []string `bson:"prompt" json:"prompt"`
+	// Maximum age in seconds of the authentication of the user, when set
+	MaxAge *int64 `bson:"max_age,omitempty" json:"max_age,omitempty"`
+	// Pushed by the client (RFC 9126) and not yet used at /authorize
+	Pushed bool `bson:"pushed" json:"pushed"`
+	// Set once the user is authenticated and the request awaits consent
+	UserID   primitive.ObjectID `bson:"user_id" json:"-"`
+	AuthTime int64              `bson:"auth_time" json:"-"`
+	// Identifier of the browser session the user signed in with
+	SessionID string `bson:"session_id" json:"-"`
+	// Posted back with the sign in and consent forms
+	CSRFToken string    `bson:"csrf_token" json:"-"`
+	ExpireAt  time.Time `bson:"expire_at" json:"expire_at"`
+}
+
+func (r *AuthorizationRequest) CollectionName() string {
+	return "authorization-requests"
+}
+
+func (r *AuthorizationRequest) CreateNew() *AuthorizationRequest {
+	return &AuthorizationRequest{
+		Entity: core.Entity{
+			ID:        primitive.NilObjectID,
+			CreatedAt: time.Now().Unix(),
+			UpdatedAt: time.Now().Unix(),
+		},
+		Scopes:   []string{},
+		ExpireAt: time.Now().Add(core.AuthorizationRequestLifetime),
+	}
+}
+
+// Loads a pending authorization request by its handle.
+// Expired and pushed requests are never returned.
+func (r *AuthorizationRequest) LoadByHandle(handle string) *AuthorizationRequest {
+	return r.loadByHandle(handle, false)
+}
+
+// Loads a pushed authorization request by the handle of its request_uri.
+// Expired requests are never returned.
+func (r *AuthorizationRequest) LoadPushedByHandle(handle string) *AuthorizationRequest {
+	return r.loadByHandle(handle, true)
+}
+
+func (r *AuthorizationRequest) loadByHandle(handle string, pushed bool) *AuthorizationRequest {
+	client := core.NewMongoClient()
+	filter := bson.M{
+		"handle":    handle,
+		"expire_at": bson.M{"$gt": time.Now()},
+	}
+	// requests stored before PAR existed have no pushed field
+	if pushed {
+		filter["pushed"] = true
+	} else {
+		filter["pushed"] = bson.M{"$ne": true}
+	}
+	result := client.FindOne(r.CollectionName(), filter)
+	if result.Err() != nil {
+		return nil
+	}
+	var request AuthorizationRequest
+	err := result.Decode(&request)
+	if err != nil {
+		return nil
+	}
+	return &request
+}
+
+func (r *AuthorizationRequest) Save() error {
+	client := core.NewMongoClient()
+	if r.ID != primitive.NilObjectID {
+		r.UpdatedAt = time.Now().Unix()
+		_, err := client.UpdateOne(r.CollectionName(), bson.M{"_id": r.ID}, bson.M{"$set": r})
+		return err
+	} else {
+		if r.Handle == "" {
+			handle, err := core.GenerateRandomString(32)
+			if err != nil {
+				return err
+			}
+			r.Handle = handle
+		}
+		if r.CSRFToken == "" {
+			csrfToken, err := core.GenerateRandomString(32)
+			if err != nil {
+				return err
+			}
+			r.CSRFToken = csrfToken
+		}
+		r.ID = primitive.NewObjectID()
+		r.CreatedAt = time.Now().Unix()
+		r.UpdatedAt = time.Now().Unix()
+		_, err := client.InsertOne(r.CollectionName(), r)
+		return err
+	}
+}
+
+// Checks the anti-CSRF value posted with the sign in and consent forms
+func (r *AuthorizationRequest) CheckCSRFToken(csrfToken string) bool {
+	return r.CSRFToken != "" && subtle.ConstantTimeCompare([]byte(csrfToken), []byte(r.CSRFToken)) == 1
+}
+
+func (r *AuthorizationRequest) Delete() error {
+	client := core.NewMongoClient()
+	_, err := client.DeleteOne(r.CollectionName(), bson.M{"_id": r.ID})
+	return err
+}
+
+// Turns a pushed request into a pending one once the user is sent to
+// /authorize. The request_uri is single use: the pushed request is deleted
+// and the pending request gets a new handle and lifetime.
+func (r *AuthorizationRequest) Redeem() (*AuthorizationRequest, error) {
+	client := core.NewMongoClient()
+	result, err := client.DeleteOne(r.CollectionName(), bson.M{"_id": r.ID, "pushed": true})
+	if err != nil {
+		return nil, err
+	}
+	if result.DeletedCount == 0 {
+		return nil, errors.New("the request_uri has already been used")
+	}
+
+	request := *r
+	request.Entity = r.CreateNew().Entity
+	request.Handle = ""
+	request.CSRFToken = ""
+	request.Pushed = false
+	request.ExpireAt = time.Now().Add(core.AuthorizationRequestLifetime)
+	err = request.Save()
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// Creates the TTL index that removes expired authorization requests
+func (r *AuthorizationRequest) CreateIndexes() error {
+	client := core.NewMongoClient()
+	return client.CreateTTLIndex(r.CollectionName(), "expire_at")
+}
@@ -0,0 +1,77 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/keyloom/web-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// A client assertion that has been used to authenticate. Its jti is kept
+// until the assertion expires so it cannot be replayed, entries are then
+// removed by a TTL index.
+type ClientAssertion struct {
+	core.Entity `bson:",inline" json:",inline"`
+	// Client ID and jti, unique across all used assertions
+	Key      string    `bson:"key" json:"key"`
+	ExpireAt time.Time `bson:"expire_at" json:"expire_at"`
+}
+
+var _ core.AssertionReplayCache = (*ClientAssertion)(nil)
+
+func (ca *ClientAssertion) CollectionName() string {
+	return "client-assertions"
+}
+
+func (ca *ClientAssertion) CreateNew() *ClientAssertion {
+	return &ClientAssertion{
+		Entity: core.Entity{
+			ID:        primitive.NilObjectID,
+			CreatedAt: time.Now().Unix(),
+			UpdatedAt: time.Now().Unix(),
+		},
+	}
+}
+
+// Consume implements core.AssertionReplayCache. The key is only inserted if
+// it is not stored yet, an assertion is fresh when this request inserted it.
+// The unique index on the key settles concurrent requests.
+func (ca *ClientAssertion) Consume(clientID, jti string, expireAt time.Time) bool {
+	client := core.NewMongoClient()
+	now := time.Now().Unix()
+	result, err := client.UpsertOne(ca.CollectionName(), bson.M{"key": clientID + ":" + jti}, bson.M{
+		"$setOnInsert": bson.M{
+			"_id":        primitive.NewObjectID(),
+			"created_at": now,
+			"updated_at": now,
+			"expire_at":  expireAt,
+		},
+	})
+	return err == nil && result.UpsertedCount == 1
+}
+
+func (ca *ClientAssertion) Save() error {
+	client := core.NewMongoClient()
+	if ca.ID != primitive.NilObjectID {
+		ca.UpdatedAt = time.Now().Unix()
+		_, err := client.UpdateOne(ca.CollectionName(), bson.M{"_id": ca.ID}, bson.M{"$set": ca})
+		return err
+	} else {
+		ca.ID = primitive.NewObjectID()
+		ca.CreatedAt = time.Now().Unix()
+		ca.UpdatedAt = time.Now().Unix()
+		_, err := client.InsertOne(ca.CollectionName(), ca)
+		return err
+	}
+}
+
+// Creates the unique index on used keys and the TTL index removing them
+func (ca *ClientAssertion) CreateIndexes() error {
+	client := core.NewMongoClient()
+	err := client.CreateUniqueIndex(ca.CollectionName(), "key")
+	if err != nil {
+		return err
+	}
+	return client.CreateTTLIndex(ca.CollectionName(), "expire_at")
+}
@@ -0,0 +1,188 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/keyloom/web-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// A device authorization (RFC 8628). The device polls with the device code
+// while the user approves the user code on another device. Only the
+// SHA-256 digest of the device code is stored.
+type DeviceCode struct {
+	core.Entity    `bson:",inline" json:",inline"`
+	DeviceCodeHash string             `bson:"device_code_hash" json:"-"`
+	UserCode       string             `bson:"user_code" json:"user_code"`
+	ClientID       string             `bson:"client_id" json:"client_id"`
+	Scopes         []string           `bson:"scopes" json:"scopes"`
+	Status         string             `bson:"status" json:"status"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"-"`
+	AuthTime       int64              `bson:"auth_time" json:"auth_time"`
+	Interval       int                `bson:"interval" json:"interval"`
+	LastPolledAt   int64              `bson:"last_polled_at" json:"last_polled_at"`
+	Consumed       bool               `bson:"consumed" json:"consumed"`
+	ExpireAt       time.Time          `bson:"expire_at" json:"expire_at"`
+}
+
+func (d *DeviceCode) CollectionName() string {
+	return "device-codes"
+}
+
+func (d *DeviceCode) CreateNew() *DeviceCode {
+	return &DeviceCode{
+		Entity: core.Entity{
+			ID:        primitive.NilObjectID,
+			CreatedAt: time.Now().Unix(),
+			UpdatedAt: time.Now().Unix(),
+		},
+		Scopes:   []string{},
+		Status:   core.DeviceCodeStatusPending,
+		Interval: core.DevicePollingInterval,
+		ExpireAt: time.Now().Add(core.DeviceCodeLifetime),
+	}
+}
+
+// Generates the device and user codes and stores the authorization.
+// The plain device code is returned and never persisted.
+func (d *DeviceCode) Issue() (string, error) {
+	deviceCode, err := core.GenerateRandomString(32)
+	if err != nil {
+		return "", err
+	}
+	userCode, err := core.GenerateUserCode()
+	if err != nil {
+		return "", err
+	}
+	d.DeviceCodeHash = (&core.Hasher{}).Digest(deviceCode)
+	d.UserCode = userCode
+	err = d.Save()
+	if err != nil {
+		return "", err
+	}
+	return deviceCode, nil
+}
+
+// Loads a device authorization by its plain device code
+func (d *DeviceCode) LoadByDeviceCode(deviceCode string) *DeviceCode {
+	client := core.NewMongoClient()
+	result := client.FindOne(d.CollectionName(), bson.M{"device_code_hash": (&core.Hasher{}).Digest(deviceCode)})
+	if result.Err() != nil {
+		return nil
+	}
+	var code DeviceCode
+	err := result.Decode(&code)
+	if err != nil {
+		return nil
+	}
+	return &code
+}
+
+// Loads a pending device authorization by its user code.
+// Expired authorizations are never returned.
+func (d *DeviceCode) LoadPendingByUserCode(userCode string) *DeviceCode {
+	client := core.NewMongoClient()
+	result := client.FindOne(d.CollectionName(), bson.M{
+		"user_code": core.NormalizeUserCode(userCode),
+		"status":    core.DeviceCodeStatusPending,
+		"expire_at": bson.M{"$gt": time.Now()},
+	})
+	if result.Err() != nil {
+		return nil
+	}
+	var code DeviceCode
+	err := result.Decode(&code)
+	if err != nil {
+		return nil
+	}
+	return &code
+}
+
+// Records a poll of the device. Returns true if the device polled faster
+// than allowed, in which case the interval is increased by 5 seconds
+// (RFC 8628 section 3.5).
+func (d *DeviceCode) RecordPoll() (bool, error) {
+	now := time.Now().Unix()
+	tooFast := d.LastPolledAt != 0 && now-d.LastPolledAt < int64(d.Interval)
+	if tooFast {
+		d.Interval += 5
+	}
+	d.LastPolledAt = now
+
+	// only the polling state is written, the user may be approving meanwhile
+	client := core.NewMongoClient()
+	_, err := client.UpdateOne(d.CollectionName(), bson.M{"_id": d.ID}, bson.M{
+		"$set": bson.M{"interval": d.Interval, "last_polled_at": d.LastPolledAt},
+	})
+	return tooFast, err
+}
+
+// Records the decision of the user on a pending authorization. Only the
+// decision is written, the device may be polling meanwhile. Returns false if
+// the authorization was already decided or has expired.
+func (d *DeviceCode) Decide(status string, userID primitive.ObjectID) (bool, error) {
+	now := time.Now()
+	client := core.NewMongoClient()
+	result, err := client.UpdateOne(d.CollectionName(), bson.M{
+		"_id":       d.ID,
+		"status":    core.DeviceCodeStatusPending,
+		"expire_at": bson.M{"$gt": now},
+	}, bson.M{
+		"$set": bson.M{
+			"status":     status,
+			"user_id":    userID,
+			"auth_time":  now.Unix(),
+			"updated_at": now.Unix(),
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	if result.ModifiedCount == 0 {
+		return false, nil
+	}
+	d.Status = status
+	d.UserID = userID
+	d.AuthTime = now.Unix()
+	return true, nil
+}
+
+// Atomically marks an approved authorization as consumed.
+// Returns false if tokens were already issued for it.
+func (d *DeviceCode) MarkConsumed() bool {
+	client := core.NewMongoClient()
+	result, err := client.UpdateOne(d.CollectionName(), bson.M{
+		"_id":      d.ID,
+		"status":   core.DeviceCodeStatusApproved,
+		"consumed": false,
+	}, bson.M{
+		"$set": bson.M{"consumed": true, "updated_at": time.Now().Unix()},
+	})
+	if err != nil || result.ModifiedCount == 0 {
+		return false
+	}
+	d.Consumed = true
+	return true
+}
+
+func (d *DeviceCode) Save() error {
+	client := core.NewMongoClient()
+	if d.ID != primitive.NilObjectID {
+		d.UpdatedAt = time.Now().Unix()
+		_, err := client.UpdateOne(d.CollectionName(), bson.M{"_id": d.ID}, bson.M{"$set": d})
+		return err
+	} else {
+		d.ID = primitive.NewObjectID()
+		d.CreatedAt = time.Now().Unix()
+		d.UpdatedAt = time.Now().Unix()
+		_, err := client.InsertOne(d.CollectionName(), d)
+		return err
+	}
+}
+
+// Creates the TTL index that removes expired device authorizations
+func (d *DeviceCode) CreateIndexes() error {
+	client := core.NewMongoClient()
+	return client.CreateTTLIndex(d.CollectionName(), "expire_at")
+}
@@ -0,0 +1,77 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/keyloom/web-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// A DPoP proof that has been accepted. Its jti is kept until the proof
+// would be rejected as too old anyway so it cannot be replayed, entries
+// are then removed by a TTL index.
+type DPoPProof struct {
+	core.Entity `bson:",inline" json:",inline"`
+	// JWK thumbprint of the key and jti, unique across all accepted proofs
+	Key      string    `bson:"key" json:"key"`
+	ExpireAt time.Time `bson:"expire_at" json:"expire_at"`
+}
+
+var _ core.AssertionReplayCache = (*DPoPProof)(nil)
+
+func (dp *DPoPProof) CollectionName() string {
+	return "dpop-proofs"
+}
+
+func (dp *DPoPProof) CreateNew() *DPoPProof {
+	return &DPoPProof{
+		Entity: core.Entity{
+			ID:        primitive.NilObjectID,
+			CreatedAt: time.Now().Unix(),
+			UpdatedAt: time.Now().Unix(),
+		},
+	}
+}
+
+// Consume implements core.AssertionReplayCache. The key is only inserted if
+// it is not stored yet, a proof is fresh when this request inserted it. The
+// unique index on the key settles concurrent requests.
+func (dp *DPoPProof) Consume(thumbprint, jti string, expireAt time.Time) bool {
+	client := core.NewMongoClient()
+	now := time.Now().Unix()
+	result, err := client.UpsertOne(dp.CollectionName(), bson.M{"key": thumbprint + ":" + jti}, bson.M{
+		"$setOnInsert": bson.M{
+			"_id":        primitive.NewObjectID(),
+			"created_at": now,
+			"updated_at": now,
+			"expire_at":  expireAt,
+		},
+	})
+	return err == nil && result.UpsertedCount == 1
+}
+
+func (dp *DPoPProof) Save() error {
+	client := core.NewMongoClient()
+	if dp.ID != primitive.NilObjectID {
+		dp.UpdatedAt = time.Now().Unix()
+		_, err := client.UpdateOne(dp.CollectionName(), bson.M{"_id": dp.ID}, bson.M{"$set": dp})
+		return err
+	} else {
+		dp.ID = primitive.NewObjectID()
+		dp.CreatedAt = time.Now().Unix()
+		dp.UpdatedAt = time.Now().Unix()
+		_, err := client.InsertOne(dp.CollectionName(), dp)
+		return err
+	}
+}
+
+// Creates the unique index on accepted keys and the TTL index removing them
+func (dp *DPoPProof) CreateIndexes() error {
+	client := core.NewMongoClient()
+	err := client.CreateUniqueIndex(dp.CollectionName(), "key")
+	if err != nil {
+		return err
+	}
+	return client.CreateTTLIndex(dp.CollectionName(), "expire_at")
+}
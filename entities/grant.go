@@ -2,6 +2,7 @@ package entities
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/keyloom/web-api/core"
@@ -13,9 +14,9 @@ import (
 type Grant struct {
 	core.Entity   `bson:",inline"`
 	Scopes        []string           `bson:"scopes" json:"scopes"`
-	UserID        primitive.ObjectID `bson:"userId" json:"-"`
+	UserID        primitive.ObjectID `bson:"userId" json:"user_id"`
 	User          *User              `bson:"-" json:"user,omitempty"`
-	ApplicationID primitive.ObjectID `bson:"applicationId" json:"-"`
+	ApplicationID primitive.ObjectID `bson:"applicationId" json:"application_id"`
 	Application   *Application       `bson:"-" json:"application,omitempty"`
 }
 
@@ -72,21 +73,22 @@ func (g *Grant) LoadAll(top int, page int) []*Grant {
 // LoadByID implements core.IEntity.
 func (g *Grant) LoadByID(id string) *Grant {
 	client := core.NewMongoClient()
-	filter := bson.M{"_id": id}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil
+	}
+	filter := bson.M{"_id": oid}
 	result := client.FindOne(g.CollectionName(), filter)
-	if result == nil {
+	if result.Err() != nil {
 		return nil
 	}
 
 	var grant Grant
-	err := result.Decode(&grant)
+	err = result.Decode(&grant)
 	if err != nil {
 		return nil
 	}
-
-	userEntity := &User{}
-	user := userEntity.LoadByID(grant.UserID.Hex())
-	grant.User = user
+	grant.expand()
 
 	return &grant
 }
@@ -94,7 +96,15 @@ func (g *Grant) LoadByID(id string) *Grant {
 // LoadByIDs implements core.IEntity.
 func (g *Grant) LoadByIDs(ids []string) []*Grant {
 	client := core.NewMongoClient()
-	filter := bson.M{"_id": bson.M{"$in": ids}}
+	oids := []primitive.ObjectID{}
+	for _, id := range ids {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			continue
+		}
+		oids = append(oids, oid)
+	}
+	filter := bson.M{"_id": bson.M{"$in": oids}}
 	cursor, err := client.FindMany(g.CollectionName(), filter, nil)
 	if err != nil {
 		return nil
@@ -108,10 +118,7 @@ func (g *Grant) LoadByIDs(ids []string) []*Grant {
 		if err != nil {
 			continue
 		}
-
-		userEntity := &User{}
-		user := userEntity.LoadByID(grant.UserID.Hex())
-		grant.User = user
+		grant.expand()
 
 		grants = append(grants, &grant)
 	}
@@ -124,15 +131,87 @@ func (g *Grant) Save() error {
 	client := core.NewMongoClient()
 	if g.ID != primitive.NilObjectID {
 		g.UpdatedAt = time.Now().Unix()
-		_, err := client.UpdateOne(g.CollectionName(), bson.M{"_id": g.ID}, g)
+		_, err := client.UpdateOne(g.CollectionName(), bson.M{"_id": g.ID}, bson.M{"$set": g})
 		return err
 	} else {
 		g.ID = primitive.NewObjectID()
+		g.CreatedAt = time.Now().Unix()
+		g.UpdatedAt = time.Now().Unix()
 		_, err := client.InsertOne(g.CollectionName(), g)
 		return err
 	}
 }
 
+// Loads the grants of a user, of an application or both with pagination,
+// newest first. Nil IDs do not filter.
+func (g *Grant) LoadFiltered(userID, applicationID primitive.ObjectID, top, page int) []*Grant {
+	client := core.NewMongoClient()
+	skip := (page - 1) * top
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetLimit(int64(top))
+	findOptions.SetSkip(int64(skip))
+
+	filter := bson.M{}
+	if userID != primitive.NilObjectID {
+		filter["userId"] = userID
+	}
+	if applicationID != primitive.NilObjectID {
+		filter["applicationId"] = applicationID
+	}
+	cursor, err := client.FindMany(g.CollectionName(), filter, findOptions)
+	if err != nil {
+		return nil
+	}
+	defer cursor.Close(context.TODO())
+
+	grants := []*Grant{}
+	for cursor.Next(context.TODO()) {
+		var grant Grant
+		err := cursor.Decode(&grant)
+		if err != nil {
+			continue
+		}
+		grant.expand()
+		grants = append(grants, &grant)
+	}
+	return grants
+}
+
+// Loads the user and the application of the grant
+func (g *Grant) expand() {
+	g.User = (&User{}).LoadByID(g.UserID.Hex())
+	g.Application = (&Application{}).LoadByID(g.ApplicationID.Hex())
+}
+
+// Loads the grant a user gave to an application
+func (g *Grant) LoadByUserAndApplication(userID, applicationID primitive.ObjectID) *Grant {
+	client := core.NewMongoClient()
+	result := client.FindOne(g.CollectionName(), bson.M{
+		"userId":        userID,
+		"applicationId": applicationID,
+	})
+	if result.Err() != nil {
+		return nil
+	}
+	var grant Grant
+	err := result.Decode(&grant)
+	if err != nil {
+		return nil
+	}
+	return &grant
+}
+
+// Returns the scopes the user allows the application to use,
+// nil when the user did not grant anything
+func (g *Grant) AllowedScopes(userID, applicationID primitive.ObjectID) []string {
+	grant := g.LoadByUserAndApplication(userID, applicationID)
+	if grant == nil {
+		return nil
+	}
+	return grant.Scopes
+}
+
 func (g *Grant) CreateDefaultGrant(migration *Migration) error {
 	adminUserConfig, err := (&core.EnvManager{}).GetAdminUserConfig()
 	if err != nil {
@@ -151,22 +230,16 @@ func (g *Grant) CreateDefaultGrant(migration *Migration) error {
 	}
 
 	// Check if default grant exists
-	client := core.NewMongoClient()
-	result := client.FindOne(g.CollectionName(), bson.M{
-		"userId":        adminUser.ID.Hex(),
-		"applicationId": defaultApp.ID.Hex(),
-	})
-	if result.Err() == nil {
+	if g.LoadByUserAndApplication(adminUser.ID, defaultApp.ID) != nil {
 		// Grant already exists
 		return nil
 	}
 
 	// Create default grant
-	defaultGrant := &Grant{
-		Scopes:        []string{"read", "write", "delete"},
-		UserID:        adminUser.ID,
-		ApplicationID: defaultApp.ID,
-	}
+	defaultGrant := g.CreateNew()
+	defaultGrant.Scopes = defaultApp.Scopes
+	defaultGrant.UserID = adminUser.ID
+	defaultGrant.ApplicationID = defaultApp.ID
 	err = defaultGrant.Save()
 	if err != nil {
 		return err
@@ -176,4 +249,67 @@ func (g *Grant) CreateDefaultGrant(migration *Migration) error {
 	return nil
 }
 
+// Aligns the scopes of the default grant with the scopes of the default
+// application, earlier versions granted scopes no application could request
+func (g *Grant) UpdateDefaultGrantScopes(migration *Migration) error {
+	adminUserConfig, err := (&core.EnvManager{}).GetAdminUserConfig()
+	if err != nil {
+		return err
+	}
+	adminUser := (&User{}).LoadByEmail(adminUserConfig.Email)
+	defaultApp := (&Application{}).LoadByName("keyloom-frontend")
+	if adminUser != nil && defaultApp != nil {
+		defaultGrant := g.LoadByUserAndApplication(adminUser.ID, defaultApp.ID)
+		if defaultGrant != nil {
+			defaultGrant.Scopes = defaultApp.Scopes
+			err := defaultGrant.Save()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// Record migration change
+	migration.Changes = append(migration.Changes, core.MigrationChangeUpdateDefaultGrantScopes)
+	return nil
+}
+
+// Deletes every grant of the user
+func (g *Grant) DeleteByUser(userID primitive.ObjectID) error {
+	client := core.NewMongoClient()
+	_, err := client.DeleteMany(g.CollectionName(), bson.M{"userId": userID})
+	return err
+}
+
+// Reports whether the grant allows all the given scopes
+func (g *Grant) Covers(scopes []string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(g.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// Records the consent of a user: the scopes are added to the user's grant
+// for the application, which is created on first consent
+func (g *Grant) Consent(userID, applicationID primitive.ObjectID, scopes []string) (*Grant, error) {
+	grant := g.LoadByUserAndApplication(userID, applicationID)
+	if grant == nil {
+		grant = g.CreateNew()
+		grant.UserID = userID
+		grant.ApplicationID = applicationID
+		grant.Scopes = []string{}
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(grant.Scopes, scope) {
+			grant.Scopes = append(grant.Scopes, scope)
+		}
+	}
+	err := grant.Save()
+	if err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
 var _ core.IEntity[Grant] = (*Grant)(nil)
@@ -0,0 +1,138 @@
+package entities
+
+import (
+	"context"
+	"time"
+
+	"github.com/keyloom/web-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// A back-channel logout notification owed to a client after a session it
+// took part in ended. Failed deliveries are retried with an increasing delay,
+// records are removed by a TTL index once retained long enough.
+type LogoutDelivery struct {
+	core.Entity `bson:",inline" json:",inline"`
+	ClientID    string             `bson:"client_id" json:"client_id"`
+	URI         string             `bson:"uri" json:"uri"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	SessionID   string             `bson:"session_id" json:"session_id"`
+	// One of pending, delivered or failed
+	Status        string    `bson:"status" json:"status"`
+	Attempts      int       `bson:"attempts" json:"attempts"`
+	LastError     string    `bson:"last_error" json:"last_error,omitempty"`
+	NextAttemptAt time.Time `bson:"next_attempt_at" json:"next_attempt_at"`
+	ExpireAt      time.Time `bson:"expire_at" json:"expire_at"`
+}
+
+func (ld *LogoutDelivery) CollectionName() string {
+	return "logout-deliveries"
+}
+
+func (ld *LogoutDelivery) CreateNew() *LogoutDelivery {
+	return &LogoutDelivery{
+		Entity: core.Entity{
+			ID:        primitive.NilObjectID,
+			CreatedAt: time.Now().Unix(),
+			UpdatedAt: time.Now().Unix(),
+		},
+		Status:        core.LogoutDeliveryStatusPending,
+		NextAttemptAt: time.Now(),
+		ExpireAt:      time.Now().Add(core.LogoutDeliveryRetention),
+	}
+}
+
+// Claims the next pending delivery that is due, so that concurrent workers
+// do not deliver it twice. The claim lasts until the attempt has timed out.
+// Returns nil if no delivery is due.
+func (ld *LogoutDelivery) ClaimNext() *LogoutDelivery {
+	client := core.NewMongoClient()
+	result := client.FindOneAndUpdate(ld.CollectionName(), bson.M{
+		"status":          core.LogoutDeliveryStatusPending,
+		"next_attempt_at": bson.M{"$lte": time.Now()},
+	}, bson.M{
+		"$set": bson.M{"next_attempt_at": time.Now().Add(2 * core.LogoutDeliveryTimeout)},
+	})
+	if result.Err() != nil {
+		return nil
+	}
+	var delivery LogoutDelivery
+	err := result.Decode(&delivery)
+	if err != nil {
+		return nil
+	}
+	return &delivery
+}
+
+// Records the outcome of a delivery attempt. Failed deliveries are retried
+// until the maximum number of attempts is reached.
+func (ld *LogoutDelivery) RecordAttempt(deliveryErr error) error {
+	ld.recordOutcome(deliveryErr, time.Now())
+	return ld.Save()
+}
+
+// Updates the status after an attempt. The delay before the next attempt
+// doubles after each failure.
+func (ld *LogoutDelivery) recordOutcome(deliveryErr error, now time.Time) {
+	ld.Attempts++
+	switch {
+	case deliveryErr == nil:
+		ld.Status = core.LogoutDeliveryStatusDelivered
+		ld.LastError = ""
+	case ld.Attempts >= core.LogoutDeliveryMaxAttempts:
+		ld.Status = core.LogoutDeliveryStatusFailed
+		ld.LastError = deliveryErr.Error()
+	default:
+		ld.LastError = deliveryErr.Error()
+		ld.NextAttemptAt = now.Add(core.LogoutDeliveryRetryDelay << (ld.Attempts - 1))
+	}
+}
+
+// Loads the deliveries to a client, newest first
+func (ld *LogoutDelivery) LoadByClientID(clientID string, top, page int) []*LogoutDelivery {
+	client := core.NewMongoClient()
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetLimit(int64(top))
+	findOptions.SetSkip(int64((page - 1) * top))
+
+	cursor, err := client.FindMany(ld.CollectionName(), bson.M{"client_id": clientID}, findOptions)
+	if err != nil {
+		return nil
+	}
+	defer cursor.Close(context.TODO())
+
+	deliveries := []*LogoutDelivery{}
+	for cursor.Next(context.TODO()) {
+		var delivery LogoutDelivery
+		err := cursor.Decode(&delivery)
+		if err != nil {
+			continue
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries
+}
+
+func (ld *LogoutDelivery) Save() error {
+	client := core.NewMongoClient()
+	if ld.ID != primitive.NilObjectID {
+		ld.UpdatedAt = time.Now().Unix()
+		_, err := client.UpdateOne(ld.CollectionName(), bson.M{"_id": ld.ID}, bson.M{"$set": ld})
+		return err
+	} else {
+		ld.ID = primitive.NewObjectID()
+		ld.CreatedAt = time.Now().Unix()
+		ld.UpdatedAt = time.Now().Unix()
+		_, err := client.InsertOne(ld.CollectionName(), ld)
+		return err
+	}
+}
+
+// Creates the TTL index that removes deliveries once retained long enough
+func (ld *LogoutDelivery) CreateIndexes() error {
+	client := core.NewMongoClient()
+	return client.CreateTTLIndex(ld.CollectionName(), "expire_at")
+}
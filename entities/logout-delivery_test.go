@@ -0,0 +1,101 @@
+package entities
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/keyloom/web-api/core"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestLogoutDeliveryRetriesWithBackoff(t *testing.T) {
+	now := time.Now()
+	delivery := (&LogoutDelivery{}).CreateNew()
+	deliveryErr := errors.New("logout URI answered with status 500")
+
+	expectedDelays := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute}
+	for attempt, delay := range expectedDelays {
+		delivery.recordOutcome(deliveryErr, now)
+		if delivery.Status != core.LogoutDeliveryStatusPending {
+			t.Fatalf("attempt %d: expected the delivery to stay pending, got %s", attempt+1, delivery.Status)
+		}
+		if got := delivery.NextAttemptAt.Sub(now); got != delay {
+			t.Fatalf("attempt %d: expected a retry in %v, got %v", attempt+1, delay, got)
+		}
+		if delivery.LastError != deliveryErr.Error() {
+			t.Fatalf("attempt %d: expected the error to be recorded", attempt+1)
+		}
+	}
+
+	delivery.recordOutcome(deliveryErr, now)
+	if delivery.Attempts != core.LogoutDeliveryMaxAttempts {
+		t.Fatalf("expected %d attempts, got %d", core.LogoutDeliveryMaxAttempts, delivery.Attempts)
+	}
+	if delivery.Status != core.LogoutDeliveryStatusFailed {
+		t.Fatalf("expected the delivery to fail after the last attempt, got %s", delivery.Status)
+	}
+}
+
+func TestLogoutDeliverySucceedsAfterRetry(t *testing.T) {
+	now := time.Now()
+	delivery := (&LogoutDelivery{}).CreateNew()
+	delivery.recordOutcome(errors.New("connection refused"), now)
+	delivery.recordOutcome(nil, now)
+
+	if delivery.Status != core.LogoutDeliveryStatusDelivered {
+		t.Fatalf("expected the delivery to succeed, got %s", delivery.Status)
+	}
+	if delivery.Attempts != 2 || delivery.LastError != "" {
+		t.Fatalf("unexpected delivery state: %d attempts, error %q", delivery.Attempts, delivery.LastError)
+	}
+}
+
+// Claims deliveries in a dedicated database of the MongoDB server configured
+// by the MONGODB_* variables, skipped when none is configured
+func TestLogoutDeliveryClaimNext(t *testing.T) {
+	if os.Getenv("MONGODB_HOST") == "" {
+		t.Skip("MONGODB_HOST is not set")
+	}
+	t.Setenv("MONGODB_DB", os.Getenv("MONGODB_DB")+"-test")
+	collection := (&LogoutDelivery{}).CollectionName()
+	cleanUp := func() {
+		core.NewMongoClient().DeleteMany(collection, bson.M{})
+	}
+	cleanUp()
+	t.Cleanup(cleanUp)
+
+	later := (&LogoutDelivery{}).CreateNew()
+	later.ClientID = "later"
+	later.NextAttemptAt = time.Now().Add(time.Hour)
+	due := (&LogoutDelivery{}).CreateNew()
+	due.ClientID = "due"
+	for _, delivery := range []*LogoutDelivery{later, due} {
+		if err := delivery.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	claimed := (&LogoutDelivery{}).ClaimNext()
+	if claimed == nil || claimed.ClientID != "due" {
+		t.Fatalf("expected the due delivery to be claimed, got %+v", claimed)
+	}
+	// the claim keeps other workers from delivering it at the same time
+	if again := (&LogoutDelivery{}).ClaimNext(); again != nil {
+		t.Fatalf("expected no other delivery to be due, got %s", again.ClientID)
+	}
+
+	// delivered and failed deliveries are never claimed again
+	if err := claimed.RecordAttempt(nil); err != nil {
+		t.Fatal(err)
+	}
+	later.Status = core.LogoutDeliveryStatusFailed
+	later.NextAttemptAt = time.Now()
+	if err := later.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if again := (&LogoutDelivery{}).ClaimNext(); again != nil {
+		t.Fatalf("expected no delivery to be claimed, got %s", again.ClientID)
+	}
+}
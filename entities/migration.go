@@ -40,7 +40,7 @@ func (m *Migration) Save() error {
 		return err
 	} else {
 		m.UpdatedAt = time.Now().Unix()
-		_, err := client.UpdateOne(m.CollectionName(), m.ID, m)
+		_, err := client.UpdateOne(m.CollectionName(), bson.M{"_id": m.ID}, bson.M{"$set": m})
 		return err
 	}
 }
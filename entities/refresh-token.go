@@ -0,0 +1,212 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/keyloom/web-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// A refresh token issued to an application on behalf of a user.
+// Every use rotates the token, all tokens descending from the same
+// original grant share a family ID. Only the SHA-256 digest is stored.
+type RefreshToken struct {
+	core.Entity   `bson:",inline" json:",inline"`
+	TokenHash     string             `bson:"token_hash" json:"-"`
+	FamilyID      primitive.ObjectID `bson:"family_id" json:"family_id"`
+	UserID        primitive.ObjectID `bson:"user_id" json:"-"`
+	ApplicationID primitive.ObjectID `bson:"application_id" json:"-"`
+	Scopes        []string           `bson:"scopes" json:"scopes"`
+	// Resource indicators the grant is limited to
+	Resources []string `bson:"resources" json:"resources"`
+	AuthTime  int64    `bson:"auth_time" json:"auth_time"`
+	// Identifier of the browser session the user signed in with
+	SessionID string `bson:"session_id" json:"-"`
+	// JWK thumbprint of the DPoP key the token of a public client is bound to
+	DPoPJkt  string    `bson:"dpop_jkt" json:"-"`
+	Consumed bool      `bson:"consumed" json:"consumed"`
+	Revoked  bool      `bson:"revoked" json:"revoked"`
+	ExpireAt time.Time `bson:"expire_at" json:"expire_at"`
+}
+
+// The presented refresh token can no longer be exchanged
+var ErrRefreshTokenInactive = errors.New("refresh token is expired or revoked")
+
+// The presented refresh token was already exchanged, so it has leaked
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+
+func (rt *RefreshToken) CollectionName() string {
+	return "refresh-tokens"
+}
+
+func (rt *RefreshToken) CreateNew() *RefreshToken {
+	return &RefreshToken{
+		Entity: core.Entity{
+			ID:        primitive.NilObjectID,
+			CreatedAt: time.Now().Unix(),
+			UpdatedAt: time.Now().Unix(),
+		},
+		Scopes: []string{},
+	}
+}
+
+// Generates a new refresh token and stores its digest.
+// A new family is started unless the family ID was already set.
+// The plain token is returned and never persisted.
+func (rt *RefreshToken) Issue() (string, error) {
+	config, err := (&core.EnvManager{}).GetTokenConfig()
+	if err != nil {
+		return "", err
+	}
+	token, err := core.GenerateRandomString(32)
+	if err != nil {
+		return "", err
+	}
+	rt.TokenHash = (&core.Hasher{}).Digest(token)
+	rt.ExpireAt = time.Now().Add(time.Duration(config.RefreshTokenDuration) * time.Minute)
+	if rt.FamilyID == primitive.NilObjectID {
+		rt.FamilyID = primitive.NewObjectID()
+	}
+	err = rt.Save()
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Loads a refresh token by its plain value
+func (rt *RefreshToken) LoadByToken(token string) *RefreshToken {
+	client := core.NewMongoClient()
+	result := client.FindOne(rt.CollectionName(), bson.M{"token_hash": (&core.Hasher{}).Digest(token)})
+	if result.Err() != nil {
+		return nil
+	}
+	var refreshToken RefreshToken
+	err := result.Decode(&refreshToken)
+	if err != nil {
+		return nil
+	}
+	return &refreshToken
+}
+
+// Returns true if the token can still be exchanged
+func (rt *RefreshToken) IsActive() bool {
+	return !rt.Consumed && !rt.Revoked && time.Now().Before(rt.ExpireAt)
+}
+
+// Atomically marks the token as consumed.
+// Returns false if the token had already been consumed.
+func (rt *RefreshToken) MarkConsumed() bool {
+	client := core.NewMongoClient()
+	result, err := client.UpdateOne(rt.CollectionName(), bson.M{
+		"_id":      rt.ID,
+		"consumed": false,
+	}, bson.M{
+		"$set": bson.M{"consumed": true, "updated_at": time.Now().Unix()},
+	})
+	if err != nil || result.ModifiedCount == 0 {
+		return false
+	}
+	rt.Consumed = true
+	return true
+}
+
+// Exchanges the token for its successor, which still has to be issued. The
+// token is consumed, and presenting a consumed token again revokes every
+// token of its family.
+func (rt *RefreshToken) Rotate() (*RefreshToken, error) {
+	now := time.Now()
+	successor, err := rt.rotate(rt.Consumed, now)
+	if err == nil && !rt.MarkConsumed() {
+		// a concurrent request consumed it first
+		successor, err = rt.rotate(true, now)
+	}
+	if errors.Is(err, ErrRefreshTokenReused) {
+		if revokeErr := rt.RevokeFamily(); revokeErr != nil {
+			return nil, revokeErr
+		}
+	}
+	return successor, err
+}
+
+// Decides the outcome of presenting the token. Active tokens that were not
+// consumed yet get a successor in the same family, with the original scopes.
+func (rt *RefreshToken) rotate(consumed bool, now time.Time) (*RefreshToken, error) {
+	if rt.Revoked || !now.Before(rt.ExpireAt) {
+		return nil, ErrRefreshTokenInactive
+	}
+	if consumed {
+		return nil, ErrRefreshTokenReused
+	}
+	successor := rt.CreateNew()
+	successor.FamilyID = rt.FamilyID
+	successor.UserID = rt.UserID
+	successor.ApplicationID = rt.ApplicationID
+	successor.Scopes = rt.Scopes
+	successor.Resources = rt.Resources
+	successor.AuthTime = rt.AuthTime
+	successor.SessionID = rt.SessionID
+	successor.DPoPJkt = rt.DPoPJkt
+	return successor, nil
+}
+
+// Revokes every token of the family this token belongs to
+func (rt *RefreshToken) RevokeFamily() error {
+	client := core.NewMongoClient()
+	_, err := client.UpdateMany(rt.CollectionName(), bson.M{
+		"family_id": rt.FamilyID,
+	}, bson.M{
+		"$set": bson.M{"revoked": true, "updated_at": time.Now().Unix()},
+	})
+	if err != nil {
+		return err
+	}
+	rt.Revoked = true
+	return nil
+}
+
+// Revokes every refresh token issued on behalf of the user
+func (rt *RefreshToken) RevokeByUser(userID primitive.ObjectID) error {
+	client := core.NewMongoClient()
+	_, err := client.UpdateMany(rt.CollectionName(), bson.M{
+		"user_id": userID,
+	}, bson.M{
+		"$set": bson.M{"revoked": true, "updated_at": time.Now().Unix()},
+	})
+	return err
+}
+
+// Revokes every refresh token issued to the application on behalf of the user
+func (rt *RefreshToken) RevokeByUserAndApplication(userID, applicationID primitive.ObjectID) error {
+	client := core.NewMongoClient()
+	_, err := client.UpdateMany(rt.CollectionName(), bson.M{
+		"user_id":        userID,
+		"application_id": applicationID,
+	}, bson.M{
+		"$set": bson.M{"revoked": true, "updated_at": time.Now().Unix()},
+	})
+	return err
+}
+
+func (rt *RefreshToken) Save() error {
+	client := core.NewMongoClient()
+	if rt.ID != primitive.NilObjectID {
+		rt.UpdatedAt = time.Now().Unix()
+		_, err := client.UpdateOne(rt.CollectionName(), bson.M{"_id": rt.ID}, bson.M{"$set": rt})
+		return err
+	} else {
+		rt.ID = primitive.NewObjectID()
+		rt.CreatedAt = time.Now().Unix()
+		rt.UpdatedAt = time.Now().Unix()
+		_, err := client.InsertOne(rt.CollectionName(), rt)
+		return err
+	}
+}
+
+// Creates the TTL index that removes expired refresh tokens
+func (rt *RefreshToken) CreateIndexes() error {
+	client := core.NewMongoClient()
+	return client.CreateTTLIndex(rt.CollectionName(), "expire_at")
+}
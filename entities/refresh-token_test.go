@@ -0,0 +1,128 @@
+package entities
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/keyloom/web-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func newTestRefreshToken(now time.Time) *RefreshToken {
+	refreshToken := (&RefreshToken{}).CreateNew()
+	refreshToken.ID = primitive.NewObjectID()
+	refreshToken.FamilyID = primitive.NewObjectID()
+	refreshToken.UserID = primitive.NewObjectID()
+	refreshToken.ApplicationID = primitive.NewObjectID()
+	refreshToken.Scopes = []string{"openid", "read"}
+	refreshToken.Resources = []string{"https://api.keyloom.test"}
+	refreshToken.AuthTime = now.Add(-time.Hour).Unix()
+	refreshToken.SessionID = "session"
+	refreshToken.DPoPJkt = "thumbprint"
+	refreshToken.ExpireAt = now.Add(time.Hour)
+	return refreshToken
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	now := time.Now()
+	presented := newTestRefreshToken(now)
+
+	successor, err := presented.rotate(false, now)
+	if err != nil {
+		t.Fatalf("expected the token to rotate: %v", err)
+	}
+	if successor.ID != primitive.NilObjectID || successor.TokenHash != "" || successor.Consumed || successor.Revoked {
+		t.Fatalf("expected a new token to issue, got %+v", successor)
+	}
+	if successor.FamilyID != presented.FamilyID {
+		t.Fatal("expected the successor to stay in the family")
+	}
+	if successor.UserID != presented.UserID || successor.ApplicationID != presented.ApplicationID ||
+		successor.AuthTime != presented.AuthTime || successor.SessionID != presented.SessionID ||
+		successor.DPoPJkt != presented.DPoPJkt {
+		t.Fatalf("expected the successor to keep the grant, got %+v", successor)
+	}
+	if len(successor.Scopes) != 2 || len(successor.Resources) != 1 {
+		t.Fatalf("expected the successor to keep the original scopes and resources, got %v %v", successor.Scopes, successor.Resources)
+	}
+}
+
+func TestRefreshTokenRotationOutcomes(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		prepare  func(*RefreshToken)
+		consumed bool
+		err      error
+	}{
+		{"active token", func(*RefreshToken) {}, false, nil},
+		{"consumed token", func(*RefreshToken) {}, true, ErrRefreshTokenReused},
+		{"revoked token", func(rt *RefreshToken) { rt.Revoked = true }, false, ErrRefreshTokenInactive},
+		{"revoked consumed token", func(rt *RefreshToken) { rt.Revoked = true }, true, ErrRefreshTokenInactive},
+		{"expired token", func(rt *RefreshToken) { rt.ExpireAt = now.Add(-time.Second) }, false, ErrRefreshTokenInactive},
+		{"token expiring now", func(rt *RefreshToken) { rt.ExpireAt = now }, false, ErrRefreshTokenInactive},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			presented := newTestRefreshToken(now)
+			test.prepare(presented)
+			successor, err := presented.rotate(test.consumed, now)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+			if (successor != nil) != (test.err == nil) {
+				t.Fatalf("expected a successor only without error, got %+v", successor)
+			}
+		})
+	}
+}
+
+// Rotates tokens in a dedicated database of the MongoDB server configured by
+// the MONGODB_* variables, skipped when none is configured
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	if os.Getenv("MONGODB_HOST") == "" {
+		t.Skip("MONGODB_HOST is not set")
+	}
+	t.Setenv("MONGODB_DB", os.Getenv("MONGODB_DB")+"-test")
+	t.Setenv("TOKEN_SECRET_KEY", "refresh-test-secret")
+	t.Setenv("TOKEN_ISSUER", "https://keyloom.test")
+	t.Setenv("TOKEN_AUDIENCE", "keyloom")
+	t.Setenv("TOKEN_DURATION", "15")
+	collection := (&RefreshToken{}).CollectionName()
+	cleanUp := func() {
+		core.NewMongoClient().DeleteMany(collection, bson.M{})
+	}
+	cleanUp()
+	t.Cleanup(cleanUp)
+
+	original := newTestRefreshToken(time.Now())
+	original.ID = primitive.NilObjectID
+	token, err := original.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	successor, err := (&RefreshToken{}).LoadByToken(token).Rotate()
+	if err != nil {
+		t.Fatalf("expected the first use to rotate the token: %v", err)
+	}
+	successorToken, err := successor.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the original token leaked and is presented again
+	_, err = (&RefreshToken{}).LoadByToken(token).Rotate()
+	if !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("expected the reuse to be detected, got %v", err)
+	}
+	if !(&RefreshToken{}).LoadByToken(successorToken).Revoked {
+		t.Fatal("expected the successor to be revoked along with its family")
+	}
+	_, err = (&RefreshToken{}).LoadByToken(successorToken).Rotate()
+	if !errors.Is(err, ErrRefreshTokenInactive) {
+		t.Fatalf("expected the revoked successor to be refused, got %v", err)
+	}
+}
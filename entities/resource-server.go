@@ -2,6 +2,7 @@ package entities
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/keyloom/web-api/core"
@@ -10,11 +11,21 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+// A permission offered by a resource server, the description is shown to
+// users when they consent to an application
+type ResourceServerScope struct {
+	Value       string `bson:"value" json:"value"`
+	Description string `bson:"description" json:"description"`
+}
+
 type ResourceServer struct {
 	core.Entity `bson:",inline" json:",inline"`
 	DisplayName string `bson:"display_name" json:"display_name"`
 	Name        string `bson:"name" json:"name"`
-	Description string `bson:"description" json:"description"`
+	// Unique audience of the tokens issued for this resource server (RFC 8707)
+	Identifier  string                `bson:"identifier" json:"identifier"`
+	Scopes      []ResourceServerScope `bson:"scopes" json:"scopes"`
+	Description string                `bson:"description" json:"description"`
 }
 
 var _ core.IEntity[ResourceServer] = (*ResourceServer)(nil)
@@ -30,6 +41,7 @@ func (a *ResourceServer) CreateNew() *ResourceServer {
 			CreatedAt: time.Now().Unix(),
 			UpdatedAt: time.Now().Unix(),
 		},
+		Scopes: []ResourceServerScope{},
 	}
 }
 
@@ -76,8 +88,16 @@ func (a *ResourceServer) LoadByID(id string) *ResourceServer {
 // Loads multiple audiences by their IDs
 func (a *ResourceServer) LoadByIDs(ids []string) []*ResourceServer {
 	client := core.NewMongoClient()
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			continue
+		}
+		oids = append(oids, oid)
+	}
 	cursor, err := client.FindMany(a.CollectionName(), map[string]interface{}{
-		"_id": map[string]interface{}{"$in": ids},
+		"_id": map[string]interface{}{"$in": oids},
 	})
 	if err != nil {
 		return nil
@@ -96,6 +116,36 @@ func (a *ResourceServer) LoadByIDs(ids []string) []*ResourceServer {
 	return audiences
 }
 
+// Loads a resource server by its unique name
+func (a *ResourceServer) LoadByName(name string) *ResourceServer {
+	client := core.NewMongoClient()
+	result := client.FindOne(a.CollectionName(), bson.M{"name": name})
+	if result.Err() != nil {
+		return nil
+	}
+	var resourceServer ResourceServer
+	err := result.Decode(&resourceServer)
+	if err != nil {
+		return nil
+	}
+	return &resourceServer
+}
+
+// Loads a resource server by its audience identifier
+func (a *ResourceServer) LoadByIdentifier(identifier string) *ResourceServer {
+	client := core.NewMongoClient()
+	result := client.FindOne(a.CollectionName(), bson.M{"identifier": identifier})
+	if result.Err() != nil {
+		return nil
+	}
+	var resourceServer ResourceServer
+	err := result.Decode(&resourceServer)
+	if err != nil {
+		return nil
+	}
+	return &resourceServer
+}
+
 func (a *ResourceServer) Save() error {
 	client := core.NewMongoClient()
 	if a.ID != primitive.NilObjectID {
@@ -121,7 +171,9 @@ func (a *ResourceServer) CreateDefaultResourceServer(migration *Migration) error
 	defaultResourceServer := a.CreateNew()
 	defaultResourceServer.DisplayName = "Keyloom Web API"
 	defaultResourceServer.Name = "keyloom-web-api"
+	defaultResourceServer.Identifier = core.ResourceIdentifierPrefix + defaultResourceServer.Name
 	defaultResourceServer.Description = "This is the default resource server. It represents the Keyloom Web API."
+	defaultResourceServer.Scopes = defaultResourceServerScopes
 
 	err := defaultResourceServer.Save()
 	if err != nil {
@@ -132,3 +184,90 @@ func (a *ResourceServer) CreateDefaultResourceServer(migration *Migration) error
 
 	return nil
 }
+
+// Scopes of the Keyloom Web API
+var defaultResourceServerScopes = []ResourceServerScope{
+	{Value: core.ViewResourceServersScope, Description: "View resource servers"},
+	{Value: core.ManageResourceServersScope, Description: "Create and update resource servers"},
+	{Value: core.ViewApplicationsScope, Description: "View applications"},
+	{Value: core.ManageApplicationsScope, Description: "Create and update applications"},
+	{Value: core.ViewUsersScope, Description: "View users"},
+	{Value: core.ManageUsersScope, Description: "Create and update users"},
+	{Value: core.ViewGrantsScope, Description: "View grants"},
+	{Value: core.ManageGrantsScope, Description: "Create, update and revoke grants"},
+}
+
+// Returns the description of the scope, if the resource server defines it
+func (a *ResourceServer) ScopeDescription(scope string) (string, bool) {
+	for _, definition := range a.Scopes {
+		if definition.Value == scope {
+			return definition.Description, true
+		}
+	}
+	return "", false
+}
+
+// Describes the scopes of the default resource server
+func (a *ResourceServer) UpdateDefaultResourceServerScopes(migration *Migration) error {
+	defaultResourceServer := a.LoadByName("keyloom-web-api")
+	if defaultResourceServer != nil && len(defaultResourceServer.Scopes) == 0 {
+		defaultResourceServer.Scopes = defaultResourceServerScopes
+		err := defaultResourceServer.Save()
+		if err != nil {
+			return err
+		}
+	}
+	migration.Changes = append(migration.Changes, core.MigrationChangeUpdateDefaultResourceServerScopes)
+	return nil
+}
+
+// Gives every resource server an identifier, makes identifiers unique and
+// links the default application to the default resource server
+func (a *ResourceServer) MigrateIdentifiers(migration *Migration) error {
+	client := core.NewMongoClient()
+	cursor, err := client.FindMany(a.CollectionName(), bson.M{"$or": []bson.M{
+		{"identifier": bson.M{"$exists": false}},
+		{"identifier": ""},
+	}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	for cursor.Next(context.TODO()) {
+		var resourceServer ResourceServer
+		err := cursor.Decode(&resourceServer)
+		if err != nil {
+			continue
+		}
+		// names were not unique, later resource servers with a taken
+		// identifier get their ID as a suffix so the unique index can be built
+		identifier := core.ResourceIdentifierPrefix + resourceServer.Name
+		if existing := a.LoadByIdentifier(identifier); existing != nil && existing.ID != resourceServer.ID {
+			identifier += ":" + resourceServer.ID.Hex()
+		}
+		resourceServer.Identifier = identifier
+		err = resourceServer.Save()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.CreateUniqueIndex(a.CollectionName(), "identifier")
+	if err != nil {
+		return err
+	}
+
+	defaultResourceServer := a.LoadByName("keyloom-web-api")
+	defaultApp := (&Application{}).LoadByName("keyloom-frontend")
+	if defaultResourceServer != nil && defaultApp != nil && !slices.Contains(defaultApp.ResourceServerIDs, defaultResourceServer.ID) {
+		defaultApp.ResourceServerIDs = append(defaultApp.ResourceServerIDs, defaultResourceServer.ID)
+		err := defaultApp.Save()
+		if err != nil {
+			return err
+		}
+	}
+
+	migration.Changes = append(migration.Changes, core.MigrationChangeMigrateResourceServerIdentifiers)
+	return nil
+}
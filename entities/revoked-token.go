@@ -0,0 +1,113 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/keyloom/web-api/core"
+	token_dtos "github.com/keyloom/web-api/dtos/token"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// A revoked access token, or all the tokens of a subject issued before a
+// point in time when no jti is set. Entries are removed by a TTL index once
+// the tokens would have expired anyway.
+type RevokedToken struct {
+	core.Entity `bson:",inline" json:",inline"`
+	JTI         string `bson:"jti" json:"jti"`
+	Subject     string `bson:"subject" json:"subject"`
+	// Limits the revocation of a subject to one client, when set
+	ClientID     string    `bson:"client_id" json:"client_id"`
+	IssuedBefore int64     `bson:"issued_before,omitempty" json:"issued_before,omitempty"`
+	ExpireAt     time.Time `bson:"expire_at" json:"expire_at"`
+}
+
+var _ core.TokenDenylist = (*RevokedToken)(nil)
+
+func (r *RevokedToken) CollectionName() string {
+	return "revoked-tokens"
+}
+
+func (r *RevokedToken) CreateNew() *RevokedToken {
+	return &RevokedToken{
+		Entity: core.Entity{
+			ID:        primitive.NilObjectID,
+			CreatedAt: time.Now().Unix(),
+			UpdatedAt: time.Now().Unix(),
+		},
+	}
+}
+
+// Adds the token to the denylist until it expires
+func (r *RevokedToken) Revoke(payload *token_dtos.JWTPayload) error {
+	if payload.Jti == "" {
+		return nil
+	}
+	revokedToken := r.CreateNew()
+	revokedToken.JTI = payload.Jti
+	revokedToken.Subject = payload.Sub
+	revokedToken.ClientID = payload.ClientID
+	revokedToken.ExpireAt = time.Unix(payload.Exp, 0)
+	return revokedToken.Save()
+}
+
+// Revokes every access token issued to the subject so far, by any client
+// or only by the given one
+func (r *RevokedToken) RevokeSubject(subject, clientID string) error {
+	config, err := (&core.EnvManager{}).GetTokenConfig()
+	if err != nil {
+		return err
+	}
+	revokedToken := r.CreateNew()
+	revokedToken.Subject = subject
+	revokedToken.ClientID = clientID
+	revokedToken.IssuedBefore = time.Now().Unix()
+	// no token issued so far outlives the configured token duration
+	revokedToken.ExpireAt = time.Now().Add(time.Duration(config.TokenDuration) * time.Minute)
+	return revokedToken.Save()
+}
+
+// IsRevoked implements core.TokenDenylist.
+func (r *RevokedToken) IsRevoked(payload *token_dtos.JWTPayload) (bool, error) {
+	filters := []bson.M{{
+		"jti":           "",
+		"subject":       payload.Sub,
+		"client_id":     bson.M{"$in": []string{"", payload.ClientID}},
+		"issued_before": bson.M{"$gte": payload.Iat},
+	}}
+	if payload.Jti != "" {
+		filters = append(filters, bson.M{"jti": payload.Jti})
+	}
+	client := core.NewMongoClient()
+	result := client.FindOne(r.CollectionName(), bson.M{"$or": filters})
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if result.Err() != nil {
+		return false, result.Err()
+	}
+	return true, nil
+}
+
+func (r *RevokedToken) Save() error {
+	client := core.NewMongoClient()
+	if r.ID != primitive.NilObjectID {
+		r.UpdatedAt = time.Now().Unix()
+		_, err := client.UpdateOne(r.CollectionName(), bson.M{"_id": r.ID}, bson.M{"$set": r})
+		return err
+	} else {
+		r.ID = primitive.NewObjectID()
+		r.CreatedAt = time.Now().Unix()
+		r.UpdatedAt = time.Now().Unix()
+		_, err := client.InsertOne(r.CollectionName(), r)
+		return err
+	}
+}
+
+// Creates the TTL index that removes entries of expired tokens
+func (r *RevokedToken) CreateIndexes() error {
+	client := core.NewMongoClient()
+	return client.CreateTTLIndex(r.CollectionName(), "expire_at")
+}
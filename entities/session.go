@@ -0,0 +1,170 @@
+package entities
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/keyloom/web-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// A browser session of a signed in user, shared by every application the
+// user authorizes while it lasts. The browser holds the session token in a
+// cookie, only its digest is stored.
+type Session struct {
+	core.Entity `bson:",inline" json:",inline"`
+	TokenHash   string `bson:"token_hash" json:"-"`
+	// Public identifier of the session, the sid claim of ID tokens
+	SID      string             `bson:"sid" json:"sid"`
+	UserID   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	AuthTime int64              `bson:"auth_time" json:"auth_time"`
+	// Clients the user was signed in to during the session
+	ClientIDs []string  `bson:"client_ids" json:"client_ids"`
+	ExpireAt  time.Time `bson:"expire_at" json:"expire_at"`
+}
+
+func (s *Session) CollectionName() string {
+	return "sessions"
+}
+
+func (s *Session) CreateNew() *Session {
+	return &Session{
+		Entity: core.Entity{
+			ID:        primitive.NilObjectID,
+			CreatedAt: time.Now().Unix(),
+			UpdatedAt: time.Now().Unix(),
+		},
+		ClientIDs: []string{},
+		ExpireAt:  time.Now().Add(core.SessionLifetime),
+	}
+}
+
+// Starts the session of a user who just authenticated, or renews it when the
+// user authenticates again. The session token is replaced every time, the
+// plain token is returned and never persisted.
+func (s *Session) Start(userID primitive.ObjectID) (string, error) {
+	token, err := core.GenerateRandomString(32)
+	if err != nil {
+		return "", err
+	}
+	if s.SID == "" {
+		s.SID, err = core.GenerateRandomString(16)
+		if err != nil {
+			return "", err
+		}
+	}
+	s.TokenHash = (&core.Hasher{}).Digest(token)
+	s.UserID = userID
+	s.AuthTime = time.Now().Unix()
+	s.ExpireAt = time.Now().Add(core.SessionLifetime)
+	err = s.Save()
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Loads the session of a session token.
+// Returns nil if the session does not exist or has expired.
+func (s *Session) LoadByToken(token string) *Session {
+	return s.loadOne(bson.M{"token_hash": (&core.Hasher{}).Digest(token)})
+}
+
+// Loads a session by its public identifier.
+// Returns nil if the session does not exist or has expired.
+func (s *Session) LoadBySID(sid string) *Session {
+	return s.loadOne(bson.M{"sid": sid})
+}
+
+func (s *Session) loadOne(filter bson.M) *Session {
+	client := core.NewMongoClient()
+	filter["expire_at"] = bson.M{"$gt": time.Now()}
+	result := client.FindOne(s.CollectionName(), filter)
+	if result.Err() != nil {
+		return nil
+	}
+	var session Session
+	err := result.Decode(&session)
+	if err != nil {
+		return nil
+	}
+	return &session
+}
+
+// Loads the sessions of a user that have not expired
+func (s *Session) LoadByUser(userID primitive.ObjectID) []*Session {
+	client := core.NewMongoClient()
+	cursor, err := client.FindMany(s.CollectionName(), bson.M{
+		"user_id":   userID,
+		"expire_at": bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		return nil
+	}
+	defer cursor.Close(context.TODO())
+
+	sessions := []*Session{}
+	for cursor.Next(context.TODO()) {
+		var session Session
+		err := cursor.Decode(&session)
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, &session)
+	}
+	return sessions
+}
+
+// Records that the user signed in to the client during the session
+func (s *Session) AddClient(clientID string) error {
+	client := core.NewMongoClient()
+	_, err := client.UpdateOne(s.CollectionName(), bson.M{"_id": s.ID}, bson.M{
+		"$addToSet": bson.M{"client_ids": clientID},
+		"$set":      bson.M{"updated_at": time.Now().Unix()},
+	})
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(s.ClientIDs, clientID) {
+		s.ClientIDs = append(s.ClientIDs, clientID)
+	}
+	return nil
+}
+
+func (s *Session) Save() error {
+	client := core.NewMongoClient()
+	if s.ID != primitive.NilObjectID {
+		s.UpdatedAt = time.Now().Unix()
+		_, err := client.UpdateOne(s.CollectionName(), bson.M{"_id": s.ID}, bson.M{"$set": s})
+		return err
+	} else {
+		s.ID = primitive.NewObjectID()
+		s.CreatedAt = time.Now().Unix()
+		s.UpdatedAt = time.Now().Unix()
+		_, err := client.InsertOne(s.CollectionName(), s)
+		return err
+	}
+}
+
+func (s *Session) Delete() error {
+	client := core.NewMongoClient()
+	_, err := client.DeleteOne(s.CollectionName(), bson.M{"_id": s.ID})
+	return err
+}
+
+// Creates the unique indexes on session tokens and identifiers and the TTL
+// index that removes expired sessions
+func (s *Session) CreateIndexes() error {
+	client := core.NewMongoClient()
+	err := client.CreateUniqueIndex(s.CollectionName(), "token_hash")
+	if err != nil {
+		return err
+	}
+	err = client.CreateUniqueIndex(s.CollectionName(), "sid")
+	if err != nil {
+		return err
+	}
+	return client.CreateTTLIndex(s.CollectionName(), "expire_at")
+}
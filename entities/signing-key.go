@@ -0,0 +1,359 @@
+package entities
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"time"
+
+	"github.com/keyloom/web-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// An asymmetric key pair used to sign tokens. The private key is stored
+// PKCS #8 encoded and encrypted, the public key is stored as PEM.
+type SigningKey struct {
+	core.Entity `bson:",inline" json:",inline"`
+	Kid         string `bson:"kid" json:"kid"`
+	Algorithm   string `bson:"algorithm" json:"algorithm"`
+	Status      string `bson:"status" json:"status"`
+	PrivateKey  string `bson:"private_key" json:"-"`
+	PublicKey   string `bson:"public_key" json:"public_key"`
+	ActivateAt  int64  `bson:"activate_at" json:"activate_at"`
+	RetireAt    int64  `bson:"retire_at" json:"retire_at"`
+	ExpireAt    int64  `bson:"expire_at" json:"expire_at"`
+}
+
+var _ core.KeyProvider = (*SigningKey)(nil)
+
+func (k *SigningKey) CollectionName() string {
+	return "signing-keys"
+}
+
+func (k *SigningKey) CreateNew() *SigningKey {
+	return &SigningKey{
+		Entity: core.Entity{
+			ID:        primitive.NilObjectID,
+			CreatedAt: time.Now().Unix(),
+			UpdatedAt: time.Now().Unix(),
+		},
+	}
+}
+
+// Generates and saves a new key pair with the given algorithm and status
+func (k *SigningKey) Generate(algorithm, status string) (*SigningKey, error) {
+	privateKey, err := core.GenerateSigningKey(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	encryptedPrivateKey, err := (&core.Cipher{}).Encrypt(privateDER)
+	if err != nil {
+		return nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	kid, err := core.GenerateRandomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	key := k.CreateNew()
+	key.Kid = kid
+	key.Algorithm = algorithm
+	key.Status = status
+	key.PrivateKey = encryptedPrivateKey
+	key.PublicKey = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	if status == core.SigningKeyStatusActive {
+		key.ActivateAt = time.Now().Unix()
+	}
+	err = key.Save()
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Loads all keys that are published for verification, newest first
+func (k *SigningKey) LoadPublished() []*SigningKey {
+	client := core.NewMongoClient()
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := client.FindMany(k.CollectionName(), bson.M{
+		"status": bson.M{"$in": []string{
+			core.SigningKeyStatusPending,
+			core.SigningKeyStatusActive,
+			core.SigningKeyStatusRetired,
+		}},
+		"$or": []bson.M{
+			{"expire_at": 0},
+			{"expire_at": bson.M{"$gt": time.Now().Unix()}},
+		},
+	}, findOptions)
+	if err != nil {
+		return nil
+	}
+	defer cursor.Close(context.TODO())
+
+	var keys []*SigningKey
+	for cursor.Next(context.TODO()) {
+		var key SigningKey
+		err := cursor.Decode(&key)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, &key)
+	}
+	return keys
+}
+
+// CurrentKey implements core.KeyProvider.
+func (k *SigningKey) CurrentKey() (*core.TokenKey, error) {
+	client := core.NewMongoClient()
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "activate_at", Value: -1}})
+	findOptions.SetLimit(1)
+
+	cursor, err := client.FindMany(k.CollectionName(), bson.M{"status": core.SigningKeyStatusActive}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	if !cursor.Next(context.TODO()) {
+		return nil, errors.New("no active signing key")
+	}
+	var key SigningKey
+	err = cursor.Decode(&key)
+	if err != nil {
+		return nil, err
+	}
+	return key.toTokenKey(true)
+}
+
+// KeyByID implements core.KeyProvider.
+func (k *SigningKey) KeyByID(kid string) (*core.TokenKey, error) {
+	client := core.NewMongoClient()
+	result := client.FindOne(k.CollectionName(), bson.M{
+		"kid": kid,
+		"status": bson.M{"$in": []string{
+			core.SigningKeyStatusActive,
+			core.SigningKeyStatusRetired,
+		}},
+	})
+	if result.Err() != nil {
+		return nil, errors.New("unknown signing key")
+	}
+	var key SigningKey
+	err := result.Decode(&key)
+	if err != nil {
+		return nil, err
+	}
+	if key.ExpireAt != 0 && key.ExpireAt <= time.Now().Unix() {
+		return nil, errors.New("signing key has expired")
+	}
+	return key.toTokenKey(false)
+}
+
+// Decodes the stored public key
+func (k *SigningKey) ParsePublicKey() (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(k.PublicKey))
+	if block == nil {
+		return nil, errors.New("invalid public key")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// Decodes the stored key material
+func (k *SigningKey) toTokenKey(includePrivateKey bool) (*core.TokenKey, error) {
+	publicKey, err := k.ParsePublicKey()
+	if err != nil {
+		return nil, err
+	}
+	tokenKey := &core.TokenKey{
+		ID:        k.Kid,
+		Algorithm: k.Algorithm,
+		PublicKey: publicKey,
+	}
+	if includePrivateKey {
+		privateDER, err := (&core.Cipher{}).Decrypt(k.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := x509.ParsePKCS8PrivateKey(privateDER)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("invalid private key")
+		}
+		tokenKey.PrivateKey = signer
+	}
+	return tokenKey, nil
+}
+
+// Rotates the signing keys. Once the active key is older than the rotation
+// interval the pending key takes over, and the previous key is retired and
+// kept for verification during the retention period. A pending key is always
+// prepared so verifiers can cache it before it is used. Every instance runs
+// the rotation, the promotion of the pending key is conditional so only one
+// of them rotates.
+func (k *SigningKey) Rotate(algorithm string, interval, retention time.Duration) error {
+	client := core.NewMongoClient()
+	now := time.Now()
+
+	// drop retired keys that no longer verify any token
+	_, err := client.DeleteMany(k.CollectionName(), bson.M{
+		"status":    core.SigningKeyStatusRetired,
+		"expire_at": bson.M{"$lte": now.Unix()},
+	})
+	if err != nil {
+		return err
+	}
+
+	// keys are listed newest first, so every instance picks the oldest
+	// pending key and promotes the same one
+	var active, pending *SigningKey
+	var outdated []*SigningKey
+	for _, key := range k.LoadPublished() {
+		switch key.Status {
+		case core.SigningKeyStatusActive:
+			if active == nil || key.ActivateAt > active.ActivateAt {
+				if active != nil {
+					outdated = append(outdated, active)
+				}
+				active = key
+			} else {
+				outdated = append(outdated, key)
+			}
+		case core.SigningKeyStatusPending:
+			pending = key
+		}
+	}
+
+	// instances that promoted different keys at once leave several active
+	// keys, only the newest one keeps signing
+	for _, key := range outdated {
+		err := key.retire(now, retention)
+		if err != nil {
+			return err
+		}
+	}
+
+	// a pending key of another algorithm is left over from a configuration change
+	if pending != nil && pending.Algorithm != algorithm {
+		err := pending.Delete()
+		if err != nil {
+			return err
+		}
+		pending = nil
+	}
+
+	if active == nil || now.Sub(time.Unix(active.ActivateAt, 0)) >= interval {
+		if pending == nil {
+			pending, err = k.Generate(algorithm, core.SigningKeyStatusPending)
+			if err != nil {
+				return err
+			}
+		}
+		promoted, err := pending.promote(now)
+		if err != nil {
+			return err
+		}
+		// another instance promoted the key first and completes the rotation
+		if !promoted {
+			return nil
+		}
+
+		if active != nil {
+			err := active.retire(now, retention)
+			if err != nil {
+				return err
+			}
+		}
+		pending = nil
+	}
+
+	if pending == nil {
+		_, err := k.Generate(algorithm, core.SigningKeyStatusPending)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Makes a pending key the active one. Returns false if the key is no longer
+// pending, because another instance promoted or deleted it.
+func (k *SigningKey) promote(now time.Time) (bool, error) {
+	client := core.NewMongoClient()
+	result := client.FindOneAndUpdate(k.CollectionName(), bson.M{
+		"_id":    k.ID,
+		"status": core.SigningKeyStatusPending,
+	}, bson.M{
+		"$set": bson.M{
+			"status":      core.SigningKeyStatusActive,
+			"activate_at": now.Unix(),
+			"updated_at":  now.Unix(),
+		},
+	})
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if result.Err() != nil {
+		return false, result.Err()
+	}
+	k.Status = core.SigningKeyStatusActive
+	k.ActivateAt = now.Unix()
+	return true, nil
+}
+
+// Retires an active key, it keeps verifying tokens during the retention period
+func (k *SigningKey) retire(now time.Time, retention time.Duration) error {
+	client := core.NewMongoClient()
+	_, err := client.UpdateOne(k.CollectionName(), bson.M{
+		"_id":    k.ID,
+		"status": core.SigningKeyStatusActive,
+	}, bson.M{
+		"$set": bson.M{
+			"status":     core.SigningKeyStatusRetired,
+			"retire_at":  now.Unix(),
+			"expire_at":  now.Add(retention).Unix(),
+			"updated_at": now.Unix(),
+		},
+	})
+	return err
+}
+
+func (k *SigningKey) Save() error {
+	client := core.NewMongoClient()
+	if k.ID != primitive.NilObjectID {
+		k.UpdatedAt = time.Now().Unix()
+		_, err := client.UpdateOne(k.CollectionName(), bson.M{"_id": k.ID}, bson.M{"$set": k})
+		return err
+	} else {
+		k.ID = primitive.NewObjectID()
+		k.CreatedAt = time.Now().Unix()
+		k.UpdatedAt = time.Now().Unix()
+		_, err := client.InsertOne(k.CollectionName(), k)
+		return err
+	}
+}
+
+func (k *SigningKey) Delete() error {
+	client := core.NewMongoClient()
+	_, err := client.DeleteOne(k.CollectionName(), bson.M{"_id": k.ID})
+	return err
+}
@@ -3,18 +3,27 @@ package entities
 import (
 	"context"
 	"errors"
+	"regexp"
 	"time"
 
 	"github.com/keyloom/web-api/core"
+	user_dtos "github.com/keyloom/web-api/dtos/user"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type User struct {
-	core.Entity `json:",inline" bson:",inline"`
-	Email       string `json:"email" bson:"email"`
-	Password    string `json:"password" bson:"password,containsany=uppercase,containsany=lowercase,containsany=numeric,min=8"`
+	core.Entity   `json:",inline" bson:",inline"`
+	Email         string `json:"email" bson:"email"`
+	EmailVerified bool   `json:"email_verified" bson:"email_verified"`
+	// bcrypt hash, never serialized
+	Password   string `json:"-" bson:"password,containsany=uppercase,containsany=lowercase,containsany=numeric,min=8"`
+	Name       string `json:"name,omitempty" bson:"name,omitempty"`
+	GivenName  string `json:"given_name,omitempty" bson:"given_name,omitempty"`
+	FamilyName string `json:"family_name,omitempty" bson:"family_name,omitempty"`
+	// active or disabled, users created before statuses existed have none
+	Status string `json:"status" bson:"status"`
 }
 
 var _ core.IEntity[User] = (*User)(nil)
@@ -30,6 +39,7 @@ func (u *User) CreateNew() *User {
 			CreatedAt: time.Now().Unix(),
 			UpdatedAt: time.Now().Unix(),
 		},
+		Status: core.UserStatusActive,
 	}
 }
 
@@ -61,23 +71,83 @@ func (u *User) LoadAll(top, page int) []*User {
 
 func (u *User) LoadByID(id string) *User {
 	client := core.NewMongoClient()
-	result := client.FindOne(u.CollectionName(), bson.M{"_id": id})
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil
+	}
+	result := client.FindOne(u.CollectionName(), bson.M{"_id": oid})
 	if result.Err() != nil {
 		return nil
 	}
 	var user User
-	err := result.Decode(&user)
+	err = result.Decode(&user)
 	if err != nil {
 		return nil
 	}
 	return &user
 }
 
+// Loads users matching the filter with pagination, oldest first
+func (u *User) LoadFiltered(filter user_dtos.UserFilterDTO, top, page int) []*User {
+	client := core.NewMongoClient()
+	skip := (page - 1) * top
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: 1}})
+	findOptions.SetLimit(int64(top))
+	findOptions.SetSkip(int64(skip))
+
+	query := bson.M{}
+	if filter.Email != "" {
+		query["email"] = bson.M{"$regex": regexp.QuoteMeta(filter.Email), "$options": "i"}
+	}
+	switch filter.Status {
+	case core.UserStatusDisabled:
+		query["status"] = core.UserStatusDisabled
+	case core.UserStatusActive:
+		query["status"] = bson.M{"$ne": core.UserStatusDisabled}
+	}
+	createdAt := bson.M{}
+	if filter.CreatedAfter != 0 {
+		createdAt["$gte"] = filter.CreatedAfter
+	}
+	if filter.CreatedBefore != 0 {
+		createdAt["$lt"] = filter.CreatedBefore
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	cursor, err := client.FindMany(u.CollectionName(), query, findOptions)
+	if err != nil {
+		return nil
+	}
+	defer cursor.Close(context.TODO())
+
+	users := []*User{}
+	for cursor.Next(context.TODO()) {
+		var user User
+		err := cursor.Decode(&user)
+		if err != nil {
+			continue
+		}
+		users = append(users, &user)
+	}
+	return users
+}
+
 // Loads multiple users by their IDs
 func (u *User) LoadByIDs(ids []string) []*User {
 	client := core.NewMongoClient()
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			continue
+		}
+		oids = append(oids, oid)
+	}
 	cursor, err := client.FindMany(u.CollectionName(), bson.M{
-		"_id": bson.M{"$in": ids},
+		"_id": bson.M{"$in": oids},
 	})
 	if err != nil {
 		return nil
@@ -147,6 +217,11 @@ func (u *User) SetPassword(password string) error {
 	return nil
 }
 
+// Reports whether the user was disabled and may not sign in
+func (u *User) IsDisabled() bool {
+	return u.Status == core.UserStatusDisabled
+}
+
 // Compares the given password with the stored hashed password
 func (u *User) CheckPassword(password string) bool {
 	hasher := core.Hasher{}
@@ -169,6 +244,22 @@ func (u *User) EmailExists(email string) bool {
 	return result.Err() == nil
 }
 
+// Activates the users created before user statuses existed
+func (u *User) SetDefaultStatuses(migration *Migration) error {
+	client := core.NewMongoClient()
+	_, err := client.UpdateMany(u.CollectionName(), bson.M{"$or": []bson.M{
+		{"status": bson.M{"$exists": false}},
+		{"status": ""},
+	}}, bson.M{
+		"$set": bson.M{"status": core.UserStatusActive},
+	})
+	if err != nil {
+		return err
+	}
+	migration.Changes = append(migration.Changes, core.MigrationChangeSetUserStatuses)
+	return nil
+}
+
 func (u *User) CreateDefaultAdminUser(migration *Migration) error {
 	envManager := &core.EnvManager{}
 	adminUserConfig, err := envManager.GetAdminUserConfig()
@@ -0,0 +1,80 @@
+// Package testcert issues the certificates the mutual-TLS tests use
+package testcert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Template of a CA valid for a day
+func CATemplate(commonName string) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+}
+
+// Template of a client certificate valid until notAfter
+func ClientTemplate(commonName string, notAfter time.Time) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName, Organization: []string{"Keyloom Tests"}},
+		NotBefore:   time.Now().Add(-2 * time.Hour),
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+}
+
+// Issues a certificate for a new key, self-signed when there is no parent
+func MustIssue(template, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		panic(err)
+	}
+	template.SerialNumber = serial
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		panic(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+	return certificate, key
+}
+
+// Trusts the CA for client certificates through TLS_CLIENT_CA_FILE. The
+// returned function removes the CA file.
+func MustTrustClientCA(ca *x509.Certificate) func() {
+	dir, err := os.MkdirTemp("", "keyloom-mtls")
+	if err != nil {
+		panic(err)
+	}
+	caFile := filepath.Join(dir, "client-ca.pem")
+	err = os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0o600)
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("TLS_CLIENT_CA_FILE", caFile)
+	return func() { os.RemoveAll(dir) }
+}
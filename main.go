@@ -2,12 +2,15 @@ package main
 
 import (
 	"log"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
 	"github.com/keyloom/web-api/controllers"
+	"github.com/keyloom/web-api/core"
 	docs "github.com/keyloom/web-api/docs"
+	"github.com/keyloom/web-api/views"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -26,14 +29,45 @@ func main() {
 	docs.SwaggerInfo.BasePath = "/"
 	e.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
+	// HTML templates for the interactive endpoints
+	e.SetHTMLTemplate(views.Templates())
+
 	// Migration setup
 	(&controllers.MigrationController{}).RunMigrations()
+	(&controllers.MigrationController{}).EnsureIndexes()
+
+	// Signing key rotation
+	(&controllers.KeyController{}).StartKeyRotation()
+
+	// Back-channel logout notifications
+	(&controllers.SessionController{}).StartLogoutDelivery()
 
 	// Controller registration
 	(&controllers.UserController{}).RegisterRoutes(e)
 	(&controllers.TokenController{}).RegisterRoutes(e)
 	(&controllers.ResourceServerController{}).RegisterRoutes(e)
 	(&controllers.ApplicationController{}).RegisterRoutes(e)
+	(&controllers.AuthorizeController{}).RegisterRoutes(e)
+	(&controllers.KeyController{}).RegisterRoutes(e)
+	(&controllers.OIDCController{}).RegisterRoutes(e)
+	(&controllers.DeviceController{}).RegisterRoutes(e)
+	(&controllers.RegistrationController{}).RegisterRoutes(e)
+	(&controllers.SessionController{}).RegisterRoutes(e)
+	(&controllers.GrantController{}).RegisterRoutes(e)
 
-	e.Run(":8080")
+	// Serve TLS, which mutual-TLS clients need, when a certificate is configured
+	tlsConfig, err := (&core.EnvManager{}).GetTLSConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if tlsConfig.CertFile == "" {
+		e.Run(":8080")
+		return
+	}
+	serverTLSConfig, err := core.NewServerTLSConfig(tlsConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	server := &http.Server{Addr: ":8443", Handler: e, TLSConfig: serverTLSConfig}
+	log.Fatal(server.ListenAndServeTLS("", ""))
 }
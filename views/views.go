@@ -0,0 +1,14 @@
+package views
+
+import (
+	"embed"
+	"html/template"
+)
+
+//go:embed *.html
+var files embed.FS
+
+// Parses the HTML templates rendered by the interactive endpoints
+func Templates() *template.Template {
+	return template.Must(template.ParseFS(files, "*.html"))
+}